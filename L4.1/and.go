@@ -0,0 +1,30 @@
+package or
+
+// And returns a channel that closes only after every input channel has
+// closed. Edge cases follow Or: no inputs give an already-closed channel and
+// a single input is returned as is.
+func And(channels ...<-chan any) <-chan any {
+	switch len(channels) {
+	case 0:
+		// No channels: return an already-closed channel
+		c := make(chan any)
+		close(c)
+		return c
+	case 1:
+		// Single channel: return it directly (no need for extra goroutine)
+		return channels[0]
+	}
+
+	// Multiple channels: a single goroutine waits on each channel in turn.
+	// The order does not matter since every channel has to close anyway.
+	andDone := make(chan any)
+	go func() {
+		defer close(andDone)
+
+		for _, ch := range channels {
+			<-ch
+		}
+	}()
+
+	return andDone
+}
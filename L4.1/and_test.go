@@ -0,0 +1,161 @@
+package or
+
+import (
+	"testing"
+	"time"
+
+	"or-channel/signal"
+)
+
+// TestAndNoChannels tests And with zero channels
+func TestAndNoChannels(t *testing.T) {
+	start := time.Now()
+	<-And()
+	duration := time.Since(start)
+
+	// Should return immediately (already closed channel)
+	if duration > 10*time.Millisecond {
+		t.Errorf("And() with no channels took too long: %v", duration)
+	}
+}
+
+// TestAndSingleChannel tests And with a single channel
+func TestAndSingleChannel(t *testing.T) {
+	start := time.Now()
+	<-And(signal.After(50 * time.Millisecond))
+	duration := time.Since(start)
+
+	// Should close after approximately 50ms
+	if duration < 40*time.Millisecond || duration > 100*time.Millisecond {
+		t.Errorf("And() with single channel took unexpected time: %v (expected ~50ms)", duration)
+	}
+}
+
+// TestAndTwoChannels tests And with two channels
+func TestAndTwoChannels(t *testing.T) {
+	start := time.Now()
+	<-And(
+		signal.After(100*time.Millisecond), // This one closes last
+		signal.After(50*time.Millisecond),
+	)
+	duration := time.Since(start)
+
+	// Should close after approximately 100ms (the slower one)
+	if duration < 90*time.Millisecond || duration > 150*time.Millisecond {
+		t.Errorf("And() with two channels took unexpected time: %v (expected ~100ms)", duration)
+	}
+}
+
+// TestAndMultipleChannels tests And with multiple channels
+func TestAndMultipleChannels(t *testing.T) {
+	start := time.Now()
+	<-And(
+		signal.After(50*time.Millisecond),
+		signal.After(200*time.Millisecond), // This one closes last
+		signal.After(10*time.Millisecond),
+		signal.After(100*time.Millisecond),
+		signal.After(150*time.Millisecond),
+	)
+	duration := time.Since(start)
+
+	// Should close after approximately 200ms (the slowest one)
+	if duration < 190*time.Millisecond || duration > 300*time.Millisecond {
+		t.Errorf("And() with multiple channels took unexpected time: %v (expected ~200ms)", duration)
+	}
+}
+
+// TestAndWithAlreadyClosedChannels tests And when all channels are already closed
+func TestAndWithAlreadyClosedChannels(t *testing.T) {
+	start := time.Now()
+	<-And(
+		signal.Closed(),
+		signal.Closed(),
+		signal.Closed(),
+	)
+	duration := time.Since(start)
+
+	// Should return almost immediately
+	if duration > 50*time.Millisecond {
+		t.Errorf("And() with already-closed channels took too long: %v (expected immediate)", duration)
+	}
+}
+
+// TestAndBlocksOnOpenChannel tests that And does not close while any channel is open
+func TestAndBlocksOnOpenChannel(t *testing.T) {
+	done := And(
+		signal.Closed(),
+		signal.Never(), // Never closes
+		signal.After(10*time.Millisecond),
+	)
+
+	select {
+	case <-done:
+		t.Error("And() closed while a channel was still open")
+	case <-time.After(100 * time.Millisecond):
+		// Good, still waiting
+	}
+}
+
+// TestAndManyChannels tests And with a large number of channels
+func TestAndManyChannels(t *testing.T) {
+	channels := make([]<-chan any, 100)
+
+	// Create 99 fast channels
+	for i := 0; i < 99; i++ {
+		channels[i] = signal.After(10 * time.Millisecond)
+	}
+
+	// One slow channel
+	channels[99] = signal.After(100 * time.Millisecond)
+
+	start := time.Now()
+	<-And(channels...)
+	duration := time.Since(start)
+
+	// Should close after approximately 100ms (the slowest one)
+	if duration < 90*time.Millisecond || duration > 200*time.Millisecond {
+		t.Errorf("And() with 100 channels took unexpected time: %v (expected ~100ms)", duration)
+	}
+}
+
+// TestAndReturnsClosedChannel verifies the returned channel is actually closed
+func TestAndReturnsClosedChannel(t *testing.T) {
+	done := And(signal.Closed(), signal.Closed())
+
+	// Try to read from it - should not block
+	select {
+	case <-done:
+		// Good, channel is closed
+	case <-time.After(100 * time.Millisecond):
+		t.Error("And() returned channel is not closed")
+	}
+
+	// Try reading again - should still work (closed channels can be read multiple times)
+	select {
+	case <-done:
+		// Good, channel is still readable
+	case <-time.After(100 * time.Millisecond):
+		t.Error("And() returned channel became blocking on second read")
+	}
+}
+
+// BenchmarkAnd2Channels benchmarks And with 2 channels
+func BenchmarkAnd2Channels(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		<-And(
+			signal.After(1*time.Millisecond),
+			signal.After(2*time.Millisecond),
+		)
+	}
+}
+
+// BenchmarkAnd10Channels benchmarks And with 10 channels
+func BenchmarkAnd10Channels(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		channels := make([]<-chan any, 10)
+		for j := 0; j < 10; j++ {
+			channels[j] = signal.After(time.Duration(j+1) * time.Millisecond)
+		}
+		<-And(channels...)
+	}
+}
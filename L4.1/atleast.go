@@ -0,0 +1,77 @@
+package or
+
+import "reflect"
+
+// AtLeast returns a channel that closes as soon as k of the input channels
+// have closed. AtLeast(1, ...) behaves like Or and AtLeast(len, ...) like And.
+// A k of zero or less gives an already-closed channel, and a k larger than
+// the number of inputs gives a channel that never closes.
+func AtLeast(k int, channels ...<-chan any) <-chan any {
+	switch {
+	case k <= 0:
+		// Nothing to wait for: return an already-closed channel
+		c := make(chan any)
+		close(c)
+		return c
+	case k > len(channels):
+		// Quorum can never be reached, so there is nothing to watch
+		return make(chan any)
+	case k == 1:
+		return Or(channels...)
+	case k == len(channels):
+		return And(channels...)
+	}
+
+	atLeastDone := make(chan any)
+	go func() {
+		defer close(atLeastDone)
+		waitQuorum(k, channels)
+	}()
+
+	return atLeastDone
+}
+
+// AtLeastIndices works like AtLeast but delivers the indices of the first k
+// channels that closed, in the order they closed. The returned channel is
+// closed right after the indices are sent.
+func AtLeastIndices(k int, channels ...<-chan any) <-chan []int {
+	out := make(chan []int, 1)
+
+	switch {
+	case k <= 0:
+		// Nothing to wait for: report an empty set right away
+		out <- []int{}
+		close(out)
+		return out
+	case k > len(channels):
+		// Quorum can never be reached, so there is nothing to watch
+		return out
+	}
+
+	go func() {
+		defer close(out)
+		out <- waitQuorum(k, channels)
+	}()
+
+	return out
+}
+
+// waitQuorum blocks in a single reflect.Select loop until k channels have
+// closed and returns their indices in closing order
+func waitQuorum(k int, channels []<-chan any) []int {
+	cases := make([]reflect.SelectCase, len(channels))
+	for i, ch := range channels {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)}
+	}
+
+	finished := make([]int, 0, k)
+	for len(finished) < k {
+		i, _, _ := reflect.Select(cases)
+		finished = append(finished, i)
+
+		// A zero Chan makes reflect.Select ignore the case from now on
+		cases[i].Chan = reflect.Value{}
+	}
+
+	return finished
+}
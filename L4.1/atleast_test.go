@@ -0,0 +1,100 @@
+package or
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"or-channel/signal"
+)
+
+// TestAtLeastZero tests AtLeast with k of zero
+func TestAtLeastZero(t *testing.T) {
+	select {
+	case <-AtLeast(0, signal.Never()):
+	case <-time.After(100 * time.Millisecond):
+		t.Error("AtLeast(0) did not return a closed channel")
+	}
+}
+
+// TestAtLeastUnreachable tests AtLeast with k larger than the number of channels
+func TestAtLeastUnreachable(t *testing.T) {
+	select {
+	case <-AtLeast(3, signal.Closed(), signal.Closed()):
+		t.Error("AtLeast() closed although the quorum cannot be reached")
+	case <-time.After(50 * time.Millisecond):
+		// Good, never closes
+	}
+}
+
+// TestAtLeastQuorum tests that AtLeast closes once k channels have closed
+func TestAtLeastQuorum(t *testing.T) {
+	start := time.Now()
+	<-AtLeast(3,
+		signal.After(10*time.Millisecond),
+		signal.Never(),
+		signal.After(100*time.Millisecond), // Third to close
+		signal.After(50*time.Millisecond),
+		signal.After(2*time.Second),
+	)
+	duration := time.Since(start)
+
+	// Should close after approximately 100ms (the third one)
+	if duration < 90*time.Millisecond || duration > 200*time.Millisecond {
+		t.Errorf("AtLeast() took unexpected time: %v (expected ~100ms)", duration)
+	}
+}
+
+// TestAtLeastMajority tests waiting for a majority of replicas
+func TestAtLeastMajority(t *testing.T) {
+	replicas := []<-chan any{
+		signal.Closed(),
+		signal.Never(),
+		signal.Closed(),
+	}
+
+	select {
+	case <-AtLeast(len(replicas)/2+1, replicas...):
+	case <-time.After(100 * time.Millisecond):
+		t.Error("AtLeast() did not close after a majority closed")
+	}
+}
+
+// TestAtLeastIndices tests that the finished channels are reported in closing order
+func TestAtLeastIndices(t *testing.T) {
+	got := <-AtLeastIndices(2,
+		signal.After(2*time.Second),
+		signal.After(60*time.Millisecond), // Second to close
+		signal.Never(),
+		signal.After(10*time.Millisecond), // First to close
+	)
+
+	if want := []int{3, 1}; !slices.Equal(got, want) {
+		t.Errorf("AtLeastIndices() = %v, expected %v", got, want)
+	}
+}
+
+// TestAtLeastIndicesEdgeCases tests AtLeastIndices with k of zero and an unreachable k
+func TestAtLeastIndicesEdgeCases(t *testing.T) {
+	if got := <-AtLeastIndices(0, signal.Never()); len(got) != 0 {
+		t.Errorf("AtLeastIndices(0) = %v, expected empty", got)
+	}
+
+	select {
+	case <-AtLeastIndices(2, signal.Closed()):
+		t.Error("AtLeastIndices() reported although the quorum cannot be reached")
+	case <-time.After(50 * time.Millisecond):
+		// Good, never reports
+	}
+}
+
+// BenchmarkAtLeast10Of100Channels benchmarks AtLeast waiting for 10 of 100 channels
+func BenchmarkAtLeast10Of100Channels(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		channels := make([]<-chan any, 100)
+		for j := range channels {
+			channels[j] = signal.After(time.Duration(j%10+1) * time.Millisecond)
+		}
+		<-AtLeast(10, channels...)
+	}
+}
@@ -1,32 +1,182 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"or-channel"
 )
 
-// sig creates a channel that closes after the specified duration
-func sig(after time.Duration) <-chan any {
-	c := make(chan any)
-	go func() {
-		defer close(c)
-		time.Sleep(after)
-	}()
-	return c
+var (
+	scenarioFile = flag.String("scenario", "", "JSON file with scenarios, see cmd/demo/scenarios.json")
+	strategy     = flag.String("strategy", "all", "Or implementation: auto, recursive, select or all")
+)
+
+// defaultDurations is used when neither durations nor a scenario file are given
+var defaultDurations = []string{"2h", "5m", "1s", "1h", "1m"}
+
+// strategies maps the -strategy names to Or implementations
+var strategies = map[string]func(...<-chan any) <-chan any{
+	"auto":      or.Or,
+	"recursive": or.OrRecursive,
+	"select":    or.OrSelect,
+}
+
+// Scenario is a named set of close delays, one per input channel
+type Scenario struct {
+	Name      string   `json:"name"`
+	Durations []string `json:"durations"`
+}
+
+// Report holds the measurements of one scenario run with one strategy
+type Report struct {
+	Winner     int           // index of the channel that closed first
+	Elapsed    time.Duration // time until Or closed
+	Overshoot  time.Duration // Elapsed minus the shortest configured delay
+	Goroutines int           // goroutines started by Or, counted right after it returned
+	Allocs     uint64        // heap allocations made while running
+	Bytes      uint64        // heap bytes allocated while running
 }
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Использование: %s [флаги] [длительность ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	scenarios, err := loadScenarios()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := []string{*strategy}
+	if *strategy == "all" {
+		names = []string{"recursive", "select"}
+	}
+	for _, name := range names {
+		if _, ok := strategies[name]; !ok {
+			log.Fatalf("неизвестная стратегия %q", name)
+		}
+	}
+
+	for _, sc := range scenarios {
+		delays, err := parseDurations(sc.Durations)
+		if err != nil {
+			log.Fatalf("сценарий %q: %v", sc.Name, err)
+		}
+
+		fmt.Printf("Сценарий %q: %d каналов\n", sc.Name, len(delays))
+		for _, name := range names {
+			r := run(strategies[name], delays)
+			fmt.Printf("  %-9s победил канал #%d (%v) завершено после %v, опоздание %v, горутин %d, аллокаций %d (%d байт)\n",
+				name, r.Winner, delays[r.Winner], r.Elapsed.Round(time.Microsecond), r.Overshoot.Round(time.Microsecond),
+				r.Goroutines, r.Allocs, r.Bytes)
+		}
+	}
+}
+
+// loadScenarios reads the scenario file, or builds a single scenario from
+// the positional arguments or the default durations
+func loadScenarios() ([]Scenario, error) {
+	if *scenarioFile != "" {
+		data, err := os.ReadFile(*scenarioFile)
+		if err != nil {
+			return nil, fmt.Errorf("чтение файла сценариев: %w", err)
+		}
+		var scenarios []Scenario
+		if err := json.Unmarshal(data, &scenarios); err != nil {
+			return nil, fmt.Errorf("разбор файла сценариев: %w", err)
+		}
+		if len(scenarios) == 0 {
+			return nil, fmt.Errorf("файл сценариев %s пуст", *scenarioFile)
+		}
+		return scenarios, nil
+	}
+
+	if flag.NArg() > 0 {
+		return []Scenario{{Name: "args", Durations: flag.Args()}}, nil
+	}
+	return []Scenario{{Name: "default", Durations: defaultDurations}}, nil
+}
+
+// parseDurations converts duration strings like "1s" or "250ms"
+func parseDurations(values []string) ([]time.Duration, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("нет ни одной длительности")
+	}
+
+	delays := make([]time.Duration, len(values))
+	for i, v := range values {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("отрицательная длительность %s", v)
+		}
+		delays[i] = d
+	}
+	return delays, nil
+}
+
+// run waits for orFn over timer-backed channels closing after delays and
+// measures the winner, timing, goroutines started and allocations
+func run(orFn func(...<-chan any) <-chan any, delays []time.Duration) Report {
+	runtime.GC()
+	base := runtime.NumGoroutine()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	// The first timer to fire records its index before closing its channel
+	var winner atomic.Int64
+	winner.Store(-1)
 	start := time.Now()
+	channels := make([]<-chan any, len(delays))
+	for i, d := range delays {
+		c := make(chan any)
+		channels[i] = c
+		if d == 0 {
+			// Closed right away: a timer would run its func on a goroutine
+			// of its own and skew the count below
+			winner.CompareAndSwap(-1, int64(i))
+			close(c)
+			continue
+		}
+		time.AfterFunc(d, func() {
+			winner.CompareAndSwap(-1, int64(i))
+			close(c)
+		})
+	}
+
+	// OrSelect starts its goroutines before returning, so they are counted
+	// even if they exit at once. OrRecursive starts the lower levels from
+	// the goroutines of the upper ones: yielding lets that tree grow.
+	done := orFn(channels...)
+	goroutines := runtime.NumGoroutine()
+	for range 100 {
+		runtime.Gosched()
+		goroutines = max(goroutines, runtime.NumGoroutine())
+	}
+	goroutines -= base
+	<-done
+	elapsed := time.Since(start)
 
-	<-or.Or(
-		sig(2*time.Hour),
-		sig(5*time.Minute),
-		sig(1*time.Second),
-		sig(1*time.Hour),
-		sig(1*time.Minute),
-	)
+	runtime.ReadMemStats(&after)
 
-	fmt.Printf("Завершено после %v\n", time.Since(start))
+	return Report{
+		Winner:     int(winner.Load()),
+		Elapsed:    elapsed,
+		Overshoot:  elapsed - slices.Min(delays),
+		Goroutines: max(goroutines, 0),
+		Allocs:     after.Mallocs - before.Mallocs,
+		Bytes:      after.TotalAlloc - before.TotalAlloc,
+	}
 }
@@ -5,26 +5,18 @@ import (
 	"time"
 
 	"or-channel"
+	"or-channel/signal"
 )
 
 // ExampleOr demonstrates the basic usage of the Or function
 func ExampleOr() {
-	sig := func(after time.Duration) <-chan any {
-		c := make(chan any)
-		go func() {
-			defer close(c)
-			time.Sleep(after)
-		}()
-		return c
-	}
-
 	start := time.Now()
 	<-or.Or(
-		sig(2*time.Hour),
-		sig(5*time.Minute),
-		sig(1*time.Second),
-		sig(1*time.Hour),
-		sig(1*time.Minute),
+		signal.After(2*time.Hour),
+		signal.After(5*time.Minute),
+		signal.After(1*time.Second),
+		signal.After(1*time.Hour),
+		signal.After(1*time.Minute),
 	)
 
 	fmt.Printf("done after %v", time.Since(start))
@@ -40,20 +32,10 @@ func ExampleOr_contextCancellation() {
 		close(userCancel)
 	}()
 
-	// Simulate a timeout
-	timeout := func(d time.Duration) <-chan any {
-		c := make(chan any)
-		go func() {
-			defer close(c)
-			time.Sleep(d)
-		}()
-		return c
-	}
-
 	start := time.Now()
 	<-or.Or(
-		userCancel,             // User clicks "cancel" button
-		timeout(5*time.Second), // Operation timeout
+		userCancel,                  // User clicks "cancel" button
+		signal.After(5*time.Second), // Operation timeout
 	)
 
 	duration := time.Since(start)
@@ -79,17 +61,8 @@ func ExampleOr_noChannels() {
 
 // ExampleOr_singleChannel demonstrates Or with a single channel
 func ExampleOr_singleChannel() {
-	sig := func(after time.Duration) <-chan any {
-		c := make(chan any)
-		go func() {
-			defer close(c)
-			time.Sleep(after)
-		}()
-		return c
-	}
-
 	start := time.Now()
-	<-or.Or(sig(50 * time.Millisecond))
+	<-or.Or(signal.After(50 * time.Millisecond))
 	duration := time.Since(start)
 
 	if duration >= 40*time.Millisecond && duration <= 100*time.Millisecond {
@@ -125,3 +98,80 @@ func ExampleOr_multipleServices() {
 	}
 	// Output: Got response from fastest service
 }
+
+// ExampleAnd demonstrates waiting until every channel is closed
+func ExampleAnd() {
+	start := time.Now()
+	<-or.And(
+		signal.After(10*time.Millisecond),
+		signal.After(50*time.Millisecond),
+		signal.After(100*time.Millisecond),
+	)
+
+	fmt.Printf("done after %v", time.Since(start))
+	// Output will be approximately: done after 100ms
+}
+
+// ExampleAnd_noChannels demonstrates And with no input channels
+func ExampleAnd_noChannels() {
+	start := time.Now()
+	<-or.And() // Returns immediately with a closed channel
+	duration := time.Since(start)
+
+	if duration < 10*time.Millisecond {
+		fmt.Println("Completed immediately")
+	}
+	// Output: Completed immediately
+}
+
+// ExampleAnd_singleChannel demonstrates And with a single channel
+func ExampleAnd_singleChannel() {
+	start := time.Now()
+	<-or.And(signal.After(50 * time.Millisecond))
+	duration := time.Since(start)
+
+	if duration >= 40*time.Millisecond && duration <= 100*time.Millisecond {
+		fmt.Println("Completed after approximately 50ms")
+	}
+	// Output: Completed after approximately 50ms
+}
+
+// ExampleAnd_multipleServices demonstrates using And to wait for all services to shut down
+func ExampleAnd_multipleServices() {
+	// Simulate several services that stop at different times
+	stopService := func(_ string, latency time.Duration) <-chan any {
+		c := make(chan any)
+		go func() {
+			defer close(c)
+			time.Sleep(latency)
+			// In a real scenario, you'd flush and close the service here
+		}()
+		return c
+	}
+
+	start := time.Now()
+	<-or.And(
+		stopService("http", 20*time.Millisecond),
+		stopService("grpc", 50*time.Millisecond), // Slowest
+		stopService("db", 30*time.Millisecond),
+	)
+
+	duration := time.Since(start)
+	if duration >= 40*time.Millisecond && duration <= 100*time.Millisecond {
+		fmt.Println("All services stopped")
+	}
+	// Output: All services stopped
+}
+
+// ExampleAtLeastIndices demonstrates waiting for a majority of replicas
+func ExampleAtLeastIndices() {
+	replicas := []<-chan any{
+		signal.After(300 * time.Millisecond),
+		signal.After(10 * time.Millisecond),
+		signal.After(100 * time.Millisecond),
+	}
+
+	finished := <-or.AtLeastIndices(len(replicas)/2+1, replicas...)
+	fmt.Println("quorum reached by replicas", finished)
+	// Output: quorum reached by replicas [1 2]
+}
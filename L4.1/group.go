@@ -0,0 +1,69 @@
+package or
+
+import "sync"
+
+// Group is an Or whose set of channels grows over time. Done closes as soon
+// as any channel passed to Add closes, or when Close is called. Every
+// watcher goroutine exits once Done is closed. The zero value is an empty
+// group ready to use.
+type Group struct {
+	mu     sync.Mutex
+	done   chan any
+	closed bool
+}
+
+// NewGroup creates an empty group whose Done channel stays open until a
+// channel is added and closes, or the group is closed
+func NewGroup() *Group {
+	return &Group{done: make(chan any)}
+}
+
+// doneLocked returns the done channel, creating it on first use so that a
+// zero Group works
+func (g *Group) doneLocked() chan any {
+	if g.done == nil {
+		g.done = make(chan any)
+	}
+	return g.done
+}
+
+// Add registers another channel with the group. Adding to a group that has
+// already fired is a no-op and starts no goroutine.
+func (g *Group) Add(ch <-chan any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return
+	}
+
+	done := g.doneLocked()
+	go func() {
+		select {
+		case <-ch:
+			g.Close()
+		case <-done:
+			// Another channel fired first or the group was closed
+		}
+	}()
+}
+
+// Done returns a channel that closes when the group fires
+func (g *Group) Done() <-chan any {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.doneLocked()
+}
+
+// Close fires the group and releases every watcher goroutine. It is safe to
+// call more than once and concurrently with Add.
+func (g *Group) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return
+	}
+	g.closed = true
+	close(g.doneLocked())
+}
@@ -0,0 +1,131 @@
+package or
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"or-channel/signal"
+)
+
+// TestGroupEmpty tests that an empty group does not fire on its own
+func TestGroupEmpty(t *testing.T) {
+	g := NewGroup()
+
+	select {
+	case <-g.Done():
+		t.Error("Group fired without any channels")
+	case <-time.After(50 * time.Millisecond):
+		// Good, still waiting
+	}
+}
+
+// TestGroupFiresOnAddedChannel tests that the group fires when an added channel closes
+func TestGroupFiresOnAddedChannel(t *testing.T) {
+	g := NewGroup()
+	g.Add(signal.Never())
+	g.Add(signal.After(2 * time.Second))
+
+	start := time.Now()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		g.Add(signal.After(30 * time.Millisecond)) // Added later, closes first
+	}()
+	<-g.Done()
+	duration := time.Since(start)
+
+	if duration < 40*time.Millisecond || duration > 100*time.Millisecond {
+		t.Errorf("Group took unexpected time: %v (expected ~50ms)", duration)
+	}
+}
+
+// TestGroupClose tests that Close fires the group and can be called repeatedly
+func TestGroupClose(t *testing.T) {
+	g := NewGroup()
+	g.Add(signal.Never())
+	g.Close()
+	g.Close()
+
+	select {
+	case <-g.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Group did not fire after Close")
+	}
+}
+
+// TestGroupZeroValue tests that a zero Group can be used without NewGroup
+func TestGroupZeroValue(t *testing.T) {
+	var g Group
+	done := g.Done()
+	g.Add(signal.After(10 * time.Millisecond))
+
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("zero Group did not fire on an added channel")
+	}
+
+	var closed Group
+	closed.Close()
+	select {
+	case <-closed.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Error("zero Group did not fire after Close")
+	}
+}
+
+// TestGroupAddAfterFire tests that adding to a fired group is safe
+func TestGroupAddAfterFire(t *testing.T) {
+	g := NewGroup()
+	g.Add(signal.Closed())
+	<-g.Done()
+
+	base := runtime.NumGoroutine()
+	g.Add(signal.Never())
+	g.Add(signal.Closed())
+
+	if n := runtime.NumGoroutine(); n > base {
+		t.Errorf("Add() on a fired group started %d goroutines", n-base)
+	}
+}
+
+// TestGroupNoLeak tests that watchers of the losing channels exit after the group fires
+func TestGroupNoLeak(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	g := NewGroup()
+	trigger := make(chan any)
+	for i := 0; i < 50; i++ {
+		g.Add(signal.Never())
+	}
+	g.Add(trigger)
+
+	close(trigger)
+	<-g.Done()
+
+	waitGoroutines(t, base)
+}
+
+// TestGroupConcurrentAdd tests Add and Close from many goroutines at once
+func TestGroupConcurrentAdd(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	g := NewGroup()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 50 {
+				g.Close()
+				return
+			}
+			g.Add(signal.Never())
+		}(i)
+	}
+	wg.Wait()
+	<-g.Done()
+
+	waitGoroutines(t, base)
+}
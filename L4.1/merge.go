@@ -0,0 +1,60 @@
+package or
+
+import "sync"
+
+// MergeUntil fans values from every input into a single channel until any
+// input closes or done fires. Values from one input keep their order. The
+// output is closed exactly once, after every forwarding goroutine has
+// exited. A value already received when the merge stops may be dropped.
+func MergeUntil[T any](done <-chan any, inputs ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	if len(inputs) == 0 {
+		// No inputs: nothing to forward, return an already-closed channel
+		close(out)
+		return out
+	}
+
+	stop := make(chan any)
+	var once sync.Once
+	fire := func() { once.Do(func() { close(stop) }) }
+
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				case v, ok := <-in:
+					if !ok {
+						// Closed input ends the whole merge
+						fire()
+						return
+					}
+					select {
+					case out <- v:
+					case <-stop:
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		select {
+		case <-done:
+			fire()
+		case <-stop:
+		}
+
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,153 @@
+package or
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"or-channel/signal"
+)
+
+// Helper function to create a channel that sends values then stays open
+func sendValues(values ...int) <-chan int {
+	c := make(chan int, len(values))
+	for _, v := range values {
+		c <- v
+	}
+	return c
+}
+
+// TestMergeUntilNoInputs tests MergeUntil with zero inputs
+func TestMergeUntilNoInputs(t *testing.T) {
+	select {
+	case _, ok := <-MergeUntil[int](signal.Never()):
+		if ok {
+			t.Error("MergeUntil() with no inputs delivered a value")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("MergeUntil() with no inputs did not return a closed channel")
+	}
+}
+
+// TestMergeUntilKeepsInputOrder tests that values from each input arrive in order
+func TestMergeUntilKeepsInputOrder(t *testing.T) {
+	a := make(chan int)
+	b := make(chan int)
+	done := make(chan any)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			a <- i
+		}
+	}()
+	go func() {
+		for i := 1000; i < 1100; i++ {
+			b <- i
+		}
+	}()
+
+	out := MergeUntil(done, a, b)
+	lastA, lastB := -1, 999
+	for n := 0; n < 200; n++ {
+		v := <-out
+		switch {
+		case v < 1000:
+			if v != lastA+1 {
+				t.Fatalf("input a out of order: got %d after %d", v, lastA)
+			}
+			lastA = v
+		default:
+			if v != lastB+1 {
+				t.Fatalf("input b out of order: got %d after %d", v, lastB)
+			}
+			lastB = v
+		}
+	}
+
+	close(done)
+	for range out {
+	}
+}
+
+// TestMergeUntilStopsOnInputClose tests that closing one input ends the merge
+func TestMergeUntilStopsOnInputClose(t *testing.T) {
+	closing := make(chan int, 2)
+	closing <- 1
+	closing <- 2
+	close(closing)
+
+	out := MergeUntil(signal.Never(), closing, make(chan int))
+
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+
+	// Values buffered before the close are forwarded, then the output closes
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("MergeUntil() forwarded %v, expected [1 2]", got)
+	}
+}
+
+// TestMergeUntilStopsOnDone tests that done ends the merge
+func TestMergeUntilStopsOnDone(t *testing.T) {
+	out := MergeUntil(signal.After(30*time.Millisecond), sendValues(1, 2, 3), make(chan int))
+
+	start := time.Now()
+	n := 0
+	for range out {
+		n++
+	}
+	duration := time.Since(start)
+
+	if n != 3 {
+		t.Errorf("MergeUntil() forwarded %d values, expected 3", n)
+	}
+	if duration < 20*time.Millisecond || duration > 100*time.Millisecond {
+		t.Errorf("MergeUntil() took unexpected time: %v (expected ~30ms)", duration)
+	}
+}
+
+// TestMergeUntilNoLeak tests that no goroutines are left after the merge stops,
+// even when nobody reads the remaining values
+func TestMergeUntilNoLeak(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	done := make(chan any)
+	out := MergeUntil(done, sendValues(1, 2, 3), sendValues(4, 5, 6), make(chan int))
+	<-out
+	close(done)
+
+	// The output must close and every forwarder must exit
+	for range out {
+	}
+	waitGoroutines(t, base)
+}
+
+// TestMergeUntilConcurrentProducers tests MergeUntil under many producers for the race detector
+func TestMergeUntilConcurrentProducers(t *testing.T) {
+	const producers = 16
+	done := make(chan any)
+	defer close(done)
+
+	inputs := make([]<-chan int, producers)
+	for i := range inputs {
+		c := make(chan int)
+		inputs[i] = c
+		go func() {
+			for j := 0; j < 50; j++ {
+				select {
+				case c <- j:
+				case <-done:
+					return
+				}
+			}
+			if i == producers-1 {
+				close(c)
+			}
+		}()
+	}
+
+	for range MergeUntil(done, inputs...) {
+	}
+}
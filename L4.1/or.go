@@ -1,6 +1,31 @@
 package or
 
+import (
+	"context"
+	"reflect"
+)
+
+// selectThreshold is the input count from which Or switches from the
+// recursive goroutine tree to a single reflect.Select loop. BenchmarkOrStrategies
+// shows the select strategy winning from about 4 inputs upwards.
+const selectThreshold = 4
+
+// maxSelectCases is the largest number of cases reflect.Select accepts
+const maxSelectCases = 65536
+
+// Or returns a channel that closes as soon as any of the input channels
+// closes. The strategy is picked by input size: small fan-in uses the
+// recursive tree, large fan-in uses reflect.Select.
 func Or(channels ...<-chan any) <-chan any {
+	if len(channels) >= selectThreshold {
+		return OrSelect(channels...)
+	}
+	return OrRecursive(channels...)
+}
+
+// OrRecursive implements Or by halving the input and spawning a goroutine
+// per level, which costs about N goroutines and N channels for N inputs
+func OrRecursive(channels ...<-chan any) <-chan any {
 	switch len(channels) {
 	case 0:
 		// No channels: return an already-closed channel
@@ -28,8 +53,93 @@ func Or(channels ...<-chan any) <-chan any {
 			// Recursive case: divide channels in half and recurse
 			m := len(channels) / 2
 			select {
-			case <-Or(channels[:m]...):
-			case <-Or(channels[m:]...):
+			case <-OrRecursive(channels[:m]...):
+			case <-OrRecursive(channels[m:]...):
+			}
+		}
+	}()
+
+	return orDone
+}
+
+// OrSelect implements Or with a single goroutine blocked in reflect.Select.
+// Inputs beyond the reflect.Select case limit are split into chunks, one
+// goroutine per chunk, so the goroutine count stays constant for any
+// realistic input size.
+func OrSelect(channels ...<-chan any) <-chan any {
+	switch len(channels) {
+	case 0:
+		// No channels: return an already-closed channel
+		c := make(chan any)
+		close(c)
+		return c
+	case 1:
+		// Single channel: return it directly (no need for extra goroutine)
+		return channels[0]
+	}
+
+	if len(channels) > maxSelectCases {
+		// Chunked case: select over each chunk, then over the chunk results
+		chunks := make([]<-chan any, 0, (len(channels)+maxSelectCases-1)/maxSelectCases)
+		for len(channels) > 0 {
+			n := min(len(channels), maxSelectCases)
+			chunks = append(chunks, OrSelect(channels[:n]...))
+			channels = channels[n:]
+		}
+		return OrSelect(chunks...)
+	}
+
+	cases := make([]reflect.SelectCase, len(channels))
+	for i, ch := range channels {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)}
+	}
+
+	orDone := make(chan any)
+	go func() {
+		defer close(orDone)
+		reflect.Select(cases)
+	}()
+
+	return orDone
+}
+
+// OrContext works like Or but also closes the returned channel when ctx is
+// cancelled. Every internal goroutine exits as soon as either an input closes
+// or ctx is done, so nothing is left blocked on channels that never close.
+func OrContext(ctx context.Context, channels ...<-chan any) <-chan any {
+	if len(channels) == 0 {
+		// No channels: return an already-closed channel
+		c := make(chan any)
+		close(c)
+		return c
+	}
+
+	// Derived context lets the winning branch tear down its siblings
+	ctx, cancel := context.WithCancel(ctx)
+	orDone := make(chan any)
+	go func() {
+		defer close(orDone)
+		defer cancel()
+
+		switch len(channels) {
+		case 1:
+			select {
+			case <-channels[0]:
+			case <-ctx.Done():
+			}
+		case 2:
+			select {
+			case <-channels[0]:
+			case <-channels[1]:
+			case <-ctx.Done():
+			}
+		default:
+			// Recursive case: both halves share ctx and exit when it is cancelled
+			m := len(channels) / 2
+			select {
+			case <-OrContext(ctx, channels[:m]...):
+			case <-OrContext(ctx, channels[m:]...):
+			case <-ctx.Done():
 			}
 		}
 	}()
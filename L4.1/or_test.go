@@ -1,30 +1,25 @@
 package or
 
 import (
+	"context"
+	"fmt"
+	"runtime"
 	"testing"
 	"time"
-)
 
-// Helper function to create a channel that closes after a duration
-func afterDuration(d time.Duration) <-chan any {
-	c := make(chan any)
-	go func() {
-		defer close(c)
-		time.Sleep(d)
-	}()
-	return c
-}
-
-// Helper function to create an immediately closed channel
-func closedChannel() <-chan any {
-	c := make(chan any)
-	close(c)
-	return c
-}
+	"or-channel/signal"
+)
 
-// Helper function to create a channel that never closes (for timeout tests)
-func neverClosingChannel() <-chan any {
-	return make(chan any)
+// Helper function to wait until the goroutine count drops back to base
+func waitGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d running, expected at most %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
 }
 
 // TestOrNoChannels tests Or with zero channels
@@ -42,7 +37,7 @@ func TestOrNoChannels(t *testing.T) {
 // TestOrSingleChannel tests Or with a single channel
 func TestOrSingleChannel(t *testing.T) {
 	start := time.Now()
-	<-Or(afterDuration(50 * time.Millisecond))
+	<-Or(signal.After(50 * time.Millisecond))
 	duration := time.Since(start)
 
 	// Should close after approximately 50ms
@@ -55,8 +50,8 @@ func TestOrSingleChannel(t *testing.T) {
 func TestOrTwoChannels(t *testing.T) {
 	start := time.Now()
 	<-Or(
-		afterDuration(100*time.Millisecond),
-		afterDuration(50*time.Millisecond), // This one closes first
+		signal.After(100*time.Millisecond),
+		signal.After(50*time.Millisecond), // This one closes first
 	)
 	duration := time.Since(start)
 
@@ -70,11 +65,11 @@ func TestOrTwoChannels(t *testing.T) {
 func TestOrMultipleChannels(t *testing.T) {
 	start := time.Now()
 	<-Or(
-		afterDuration(2*time.Second),
-		afterDuration(500*time.Millisecond),
-		afterDuration(100*time.Millisecond), // This one closes first
-		afterDuration(1*time.Second),
-		afterDuration(300*time.Millisecond),
+		signal.After(2*time.Second),
+		signal.After(500*time.Millisecond),
+		signal.After(100*time.Millisecond), // This one closes first
+		signal.After(1*time.Second),
+		signal.After(300*time.Millisecond),
 	)
 	duration := time.Since(start)
 
@@ -88,9 +83,9 @@ func TestOrMultipleChannels(t *testing.T) {
 func TestOrWithAlreadyClosedChannel(t *testing.T) {
 	start := time.Now()
 	<-Or(
-		afterDuration(1*time.Second),
-		closedChannel(), // Already closed
-		afterDuration(2*time.Second),
+		signal.After(1*time.Second),
+		signal.Closed(), // Already closed
+		signal.After(2*time.Second),
 	)
 	duration := time.Since(start)
 
@@ -104,9 +99,9 @@ func TestOrWithAlreadyClosedChannel(t *testing.T) {
 func TestOrDoesNotBlockOnSlowChannels(t *testing.T) {
 	start := time.Now()
 	<-Or(
-		neverClosingChannel(),              // Never closes
-		afterDuration(50*time.Millisecond), // Fast channel
-		neverClosingChannel(),              // Never closes
+		signal.Never(),                    // Never closes
+		signal.After(50*time.Millisecond), // Fast channel
+		signal.Never(),                    // Never closes
 	)
 	duration := time.Since(start)
 
@@ -122,11 +117,11 @@ func TestOrManyChannels(t *testing.T) {
 
 	// Create 99 slow channels
 	for i := 0; i < 99; i++ {
-		channels[i] = afterDuration(10 * time.Second)
+		channels[i] = signal.After(10 * time.Second)
 	}
 
 	// One fast channel
-	channels[99] = afterDuration(50 * time.Millisecond)
+	channels[99] = signal.After(50 * time.Millisecond)
 
 	start := time.Now()
 	<-Or(channels...)
@@ -140,7 +135,7 @@ func TestOrManyChannels(t *testing.T) {
 
 // TestOrReturnsClosedChannel verifies the returned channel is actually closed
 func TestOrReturnsClosedChannel(t *testing.T) {
-	done := Or(closedChannel())
+	done := Or(signal.Closed())
 
 	// Try to read from it - should not block
 	select {
@@ -159,12 +154,150 @@ func TestOrReturnsClosedChannel(t *testing.T) {
 	}
 }
 
+// TestOrSelectMultipleChannels tests OrSelect with multiple channels
+func TestOrSelectMultipleChannels(t *testing.T) {
+	start := time.Now()
+	<-OrSelect(
+		signal.After(2*time.Second),
+		signal.Never(),
+		signal.After(50*time.Millisecond), // This one closes first
+		signal.After(1*time.Second),
+	)
+	duration := time.Since(start)
+
+	if duration < 40*time.Millisecond || duration > 100*time.Millisecond {
+		t.Errorf("OrSelect() took unexpected time: %v (expected ~50ms)", duration)
+	}
+}
+
+// TestOrSelectEdgeCases tests OrSelect with zero and one channel
+func TestOrSelectEdgeCases(t *testing.T) {
+	select {
+	case <-OrSelect():
+	case <-time.After(100 * time.Millisecond):
+		t.Error("OrSelect() with no channels did not return a closed channel")
+	}
+
+	c := make(chan any)
+	if OrSelect(c) != (<-chan any)(c) {
+		t.Error("OrSelect() with single channel did not return it directly")
+	}
+}
+
+// TestOrSelectBeyondCaseLimit tests OrSelect with more inputs than reflect.Select accepts
+func TestOrSelectBeyondCaseLimit(t *testing.T) {
+	never := signal.Never()
+	channels := make([]<-chan any, maxSelectCases+10)
+	for i := range channels {
+		channels[i] = never
+	}
+	trigger := make(chan any)
+	channels[len(channels)-1] = trigger
+
+	done := OrSelect(channels...)
+	close(trigger)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("OrSelect() beyond the case limit did not close")
+	}
+}
+
+// TestOrPicksStrategy tests that Or works on both sides of the strategy threshold
+func TestOrPicksStrategy(t *testing.T) {
+	for _, n := range []int{selectThreshold - 1, selectThreshold, 4 * selectThreshold} {
+		channels := make([]<-chan any, n)
+		for i := range channels {
+			channels[i] = signal.After(10 * time.Second)
+		}
+		channels[n/2] = signal.After(20 * time.Millisecond)
+
+		select {
+		case <-Or(channels...):
+		case <-time.After(time.Second):
+			t.Errorf("Or() with %d channels did not close", n)
+		}
+	}
+}
+
+// TestOrContextNoChannels tests OrContext with zero channels
+func TestOrContextNoChannels(t *testing.T) {
+	select {
+	case <-OrContext(context.Background()):
+	case <-time.After(100 * time.Millisecond):
+		t.Error("OrContext() with no channels did not return a closed channel")
+	}
+}
+
+// TestOrContextFirstClose tests that OrContext closes when the fastest channel closes
+func TestOrContextFirstClose(t *testing.T) {
+	start := time.Now()
+	<-OrContext(context.Background(),
+		signal.Never(),
+		signal.After(50*time.Millisecond), // This one closes first
+		signal.Never(),
+		signal.Never(),
+		signal.Never(),
+	)
+	duration := time.Since(start)
+
+	if duration < 40*time.Millisecond || duration > 100*time.Millisecond {
+		t.Errorf("OrContext() took unexpected time: %v (expected ~50ms)", duration)
+	}
+}
+
+// TestOrContextCancel tests that cancelling the context closes the result
+// and stops every internal goroutine
+func TestOrContextCancel(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	channels := make([]<-chan any, 100)
+	for i := range channels {
+		channels[i] = signal.Never()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := OrContext(ctx, channels...)
+	if runtime.NumGoroutine() <= base {
+		t.Fatal("OrContext() did not start any goroutines")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("OrContext() did not close after cancel")
+	}
+
+	waitGoroutines(t, base)
+}
+
+// TestOrContextNoLeakAfterClose tests that goroutines waiting on the slow
+// channels exit once one input closes, without cancelling the context
+func TestOrContextNoLeakAfterClose(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	trigger := make(chan any)
+	channels := make([]<-chan any, 50)
+	for i := range channels {
+		channels[i] = signal.Never()
+	}
+	channels[17] = trigger
+
+	done := OrContext(context.Background(), channels...)
+	close(trigger)
+	<-done
+
+	waitGoroutines(t, base)
+}
+
 // BenchmarkOr2Channels benchmarks Or with 2 channels
 func BenchmarkOr2Channels(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		<-Or(
-			afterDuration(1*time.Millisecond),
-			afterDuration(2*time.Millisecond),
+			signal.After(1*time.Millisecond),
+			signal.After(2*time.Millisecond),
 		)
 	}
 }
@@ -174,7 +307,7 @@ func BenchmarkOr10Channels(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		channels := make([]<-chan any, 10)
 		for j := 0; j < 10; j++ {
-			channels[j] = afterDuration(time.Duration(j+1) * time.Millisecond)
+			channels[j] = signal.After(time.Duration(j+1) * time.Millisecond)
 		}
 		<-Or(channels...)
 	}
@@ -184,10 +317,44 @@ func BenchmarkOr10Channels(b *testing.B) {
 func BenchmarkOr100Channels(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		channels := make([]<-chan any, 100)
-		channels[0] = afterDuration(1 * time.Millisecond)
+		channels[0] = signal.After(1 * time.Millisecond)
 		for j := 1; j < 100; j++ {
-			channels[j] = afterDuration(time.Duration(j+1) * time.Second)
+			channels[j] = signal.After(time.Duration(j+1) * time.Second)
 		}
 		<-Or(channels...)
 	}
 }
+
+// benchmarkStrategy measures setting up an Or over n open channels and
+// waiting for it after all of them are closed, so no goroutines are leaked
+func benchmarkStrategy(b *testing.B, n int, or func(...<-chan any) <-chan any) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		raw := make([]chan any, n)
+		channels := make([]<-chan any, n)
+		for j := range raw {
+			raw[j] = make(chan any)
+			channels[j] = raw[j]
+		}
+		done := or(channels...)
+		for _, c := range raw {
+			close(c)
+		}
+		<-done
+	}
+}
+
+// BenchmarkOrStrategies compares the recursive and reflect.Select strategies
+func BenchmarkOrStrategies(b *testing.B) {
+	for _, n := range []int{2, 64, 1024, 10000} {
+		b.Run(fmt.Sprintf("recursive/%d", n), func(b *testing.B) {
+			benchmarkStrategy(b, n, OrRecursive)
+		})
+		b.Run(fmt.Sprintf("select/%d", n), func(b *testing.B) {
+			benchmarkStrategy(b, n, OrSelect)
+		})
+		b.Run(fmt.Sprintf("auto/%d", n), func(b *testing.B) {
+			benchmarkStrategy(b, n, Or)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package or
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"or-channel/signal"
+)
+
+// strategies lists every Or implementation checked by the property harness
+var strategies = map[string]func(...<-chan any) <-chan any{
+	"Or":          Or,
+	"OrRecursive": OrRecursive,
+	"OrSelect":    OrSelect,
+	"OrContext": func(channels ...<-chan any) <-chan any {
+		return OrContext(context.Background(), channels...)
+	},
+}
+
+// checkOrSchedule runs or over channels closing after the given delays on
+// the synctest fake clock. It checks that the result closes exactly at the
+// earliest delay, never before, and that no goroutine is left once every
+// input has closed (synctest.Test fails on leftover goroutines).
+func checkOrSchedule(t *testing.T, or func(...<-chan any) <-chan any, delays []time.Duration) {
+	t.Helper()
+
+	synctest.Test(t, func(t *testing.T) {
+		start := time.Now()
+
+		channels := make([]<-chan any, len(delays))
+		for i, d := range delays {
+			channels[i] = signal.After(d)
+		}
+		done := or(channels...)
+
+		var earliest time.Duration
+		if len(delays) > 0 {
+			earliest = slices.Min(delays)
+		}
+
+		if earliest > 0 {
+			// Just before the earliest close nothing may have fired yet
+			time.Sleep(earliest - time.Nanosecond)
+			synctest.Wait()
+			select {
+			case <-done:
+				t.Fatalf("closed after %v, before the earliest input at %v (delays %v)", time.Since(start), earliest, delays)
+			default:
+			}
+		}
+
+		<-done
+		if got := time.Since(start); got != earliest {
+			t.Fatalf("closed after %v, expected exactly %v (delays %v)", got, earliest, delays)
+		}
+
+		// Let every remaining input close so their goroutines can exit
+		if len(delays) > 0 {
+			time.Sleep(slices.Max(delays) - earliest)
+		}
+	})
+}
+
+// delaysFromBytes turns fuzz input into close delays, one millisecond step per byte value
+func delaysFromBytes(data []byte) []time.Duration {
+	delays := make([]time.Duration, len(data))
+	for i, b := range data {
+		delays[i] = time.Duration(b) * time.Millisecond
+	}
+	return delays
+}
+
+// TestOrProperties checks every strategy against random close schedules
+func TestOrProperties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for name, or := range strategies {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				data := make([]byte, rng.Intn(64))
+				rng.Read(data)
+				checkOrSchedule(t, or, delaysFromBytes(data))
+			}
+		})
+	}
+}
+
+// FuzzOr checks every strategy against close schedules built from fuzz input
+func FuzzOr(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0})
+	f.Add([]byte{5, 5})
+	f.Add([]byte{200, 10, 0, 255})
+	f.Add([]byte{9, 8, 7, 6, 5, 4, 3, 2, 1, 1, 2, 3})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 1024 {
+			data = data[:1024]
+		}
+		delays := delaysFromBytes(data)
+
+		for name, or := range strategies {
+			t.Run(name, func(t *testing.T) {
+				checkOrSchedule(t, or, delays)
+			})
+		}
+	})
+}
@@ -0,0 +1,72 @@
+// Package signal provides ready-made done channels to combine with or.Or,
+// or.And and the other combinators. Every primitive either needs no
+// goroutine at all or stops its goroutine when its context is cancelled,
+// so exiting early never leaves anything blocked.
+package signal
+
+import (
+	"context"
+	"os"
+	ossignal "os/signal"
+	"time"
+)
+
+// After returns a channel that closes after the duration. It is backed by a
+// timer rather than a sleeping goroutine, so an unused channel costs nothing.
+func After(d time.Duration) <-chan any {
+	c := make(chan any)
+	time.AfterFunc(d, func() { close(c) })
+	return c
+}
+
+// Closed returns an already-closed channel
+func Closed() <-chan any {
+	c := make(chan any)
+	close(c)
+	return c
+}
+
+// Never returns a channel that never closes
+func Never() <-chan any {
+	return make(chan any)
+}
+
+// FromContext returns a channel that closes when ctx is done. No goroutine
+// is kept waiting on ctx, the close is registered with context.AfterFunc.
+func FromContext(ctx context.Context) <-chan any {
+	c := make(chan any)
+	context.AfterFunc(ctx, func() { close(c) })
+	return c
+}
+
+// FromSignal returns a channel that closes when the process receives one of
+// the OS signals, or when ctx is done. Signal delivery is stopped afterwards.
+func FromSignal(ctx context.Context, sigs ...os.Signal) <-chan any {
+	notify := make(chan os.Signal, 1)
+	ossignal.Notify(notify, sigs...)
+
+	c := make(chan any)
+	go func() {
+		defer close(c)
+		defer ossignal.Stop(notify)
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+		}
+	}()
+
+	return c
+}
+
+// FromFunc runs f in a goroutine and returns a channel that closes when f
+// returns. f receives ctx and is expected to return once ctx is done.
+func FromFunc(ctx context.Context, f func(ctx context.Context)) <-chan any {
+	c := make(chan any)
+	go func() {
+		defer close(c)
+		f(ctx)
+	}()
+
+	return c
+}
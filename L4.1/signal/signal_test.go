@@ -0,0 +1,119 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// Helper function to check whether a channel closes within a timeout
+func closesWithin(c <-chan any, d time.Duration) bool {
+	select {
+	case <-c:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// TestAfter tests that After closes after the duration
+func TestAfter(t *testing.T) {
+	start := time.Now()
+	<-After(50 * time.Millisecond)
+	duration := time.Since(start)
+
+	if duration < 40*time.Millisecond || duration > 100*time.Millisecond {
+		t.Errorf("After() took unexpected time: %v (expected ~50ms)", duration)
+	}
+}
+
+// TestAfterNoGoroutine tests that After does not keep a goroutine while waiting
+func TestAfterNoGoroutine(t *testing.T) {
+	base := runtime.NumGoroutine()
+	After(time.Hour)
+
+	if n := runtime.NumGoroutine(); n > base {
+		t.Errorf("After() started %d goroutines, expected none", n-base)
+	}
+}
+
+// TestClosedAndNever tests the constant signals
+func TestClosedAndNever(t *testing.T) {
+	if !closesWithin(Closed(), 10*time.Millisecond) {
+		t.Error("Closed() returned an open channel")
+	}
+	if closesWithin(Never(), 50*time.Millisecond) {
+		t.Error("Never() returned a closed channel")
+	}
+}
+
+// TestFromContext tests that FromContext closes on cancel
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := FromContext(ctx)
+
+	if closesWithin(c, 20*time.Millisecond) {
+		t.Fatal("FromContext() closed before cancel")
+	}
+	cancel()
+	if !closesWithin(c, 100*time.Millisecond) {
+		t.Error("FromContext() did not close after cancel")
+	}
+}
+
+// TestFromSignal tests that FromSignal closes when the signal arrives
+func TestFromSignal(t *testing.T) {
+	c := FromSignal(context.Background(), os.Interrupt)
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot send signal on this platform: %v", err)
+	}
+
+	if !closesWithin(c, time.Second) {
+		t.Error("FromSignal() did not close after the signal")
+	}
+}
+
+// TestFromSignalCancel tests that FromSignal stops its goroutine on cancel
+func TestFromSignalCancel(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := FromSignal(ctx, os.Interrupt)
+	cancel()
+
+	if !closesWithin(c, 100*time.Millisecond) {
+		t.Fatal("FromSignal() did not close after cancel")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("FromSignal() leaked goroutines: %d running, expected at most %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestFromFunc tests that FromFunc closes when the function returns or ctx is cancelled
+func TestFromFunc(t *testing.T) {
+	if !closesWithin(FromFunc(context.Background(), func(context.Context) {}), 100*time.Millisecond) {
+		t.Error("FromFunc() did not close after the function returned")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := FromFunc(ctx, func(ctx context.Context) { <-ctx.Done() })
+	if closesWithin(c, 20*time.Millisecond) {
+		t.Fatal("FromFunc() closed before the function returned")
+	}
+	cancel()
+	if !closesWithin(c, 100*time.Millisecond) {
+		t.Error("FromFunc() did not close after cancel")
+	}
+}
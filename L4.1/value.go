@@ -0,0 +1,52 @@
+package or
+
+import "reflect"
+
+// Result describes which channel fired first in OrValue and what it carried
+type Result[T any] struct {
+	Index  int  // index of the winning channel in the argument list
+	Value  T    // value received from the channel, zero value if it was closed
+	Closed bool // true if the channel was closed rather than sent a value
+}
+
+// OrValue waits until any of the channels sends a value or is closed and
+// delivers a single Result describing it. The returned channel is closed
+// right after the Result is sent. Exactly one value is consumed from the
+// inputs, so the losing channels are left untouched.
+func OrValue[T any](channels ...<-chan T) <-chan Result[T] {
+	out := make(chan Result[T], 1)
+
+	if len(channels) == 0 {
+		// No channels: nothing can fire, return an already-closed channel
+		close(out)
+		return out
+	}
+
+	go func() {
+		defer close(out)
+
+		if len(channels) == 1 {
+			// Single channel: plain receive, no need for reflection
+			v, ok := <-channels[0]
+			out <- Result[T]{Index: 0, Value: v, Closed: !ok}
+			return
+		}
+
+		// A single select over all channels guarantees only the winner is read
+		cases := make([]reflect.SelectCase, len(channels))
+		for i, ch := range channels {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)}
+		}
+
+		i, v, ok := reflect.Select(cases)
+		res := Result[T]{Index: i, Closed: !ok}
+		if ok {
+			// A nil sent on an interface-typed channel comes back as a nil
+			// interface, which the assertion rejects; keep the zero value
+			res.Value, _ = v.Interface().(T)
+		}
+		out <- res
+	}()
+
+	return out
+}
@@ -0,0 +1,130 @@
+package or
+
+import (
+	"testing"
+	"time"
+)
+
+// Helper function to create a typed channel that sends v after a duration
+func sendAfter[T any](d time.Duration, v T) <-chan T {
+	c := make(chan T, 1)
+	go func() {
+		time.Sleep(d)
+		c <- v
+	}()
+	return c
+}
+
+// TestOrValueNoChannels tests OrValue with zero channels
+func TestOrValueNoChannels(t *testing.T) {
+	select {
+	case _, ok := <-OrValue[int]():
+		if ok {
+			t.Error("OrValue() with no channels delivered a result")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("OrValue() with no channels did not return a closed channel")
+	}
+}
+
+// TestOrValueSingleChannel tests OrValue with a single channel
+func TestOrValueSingleChannel(t *testing.T) {
+	res := <-OrValue(sendAfter(10*time.Millisecond, "ready"))
+
+	if res.Index != 0 || res.Value != "ready" || res.Closed {
+		t.Errorf("OrValue() returned unexpected result: %+v", res)
+	}
+}
+
+// TestOrValueReportsWinner tests that OrValue reports the index and value of the fastest channel
+func TestOrValueReportsWinner(t *testing.T) {
+	res := <-OrValue(
+		sendAfter(200*time.Millisecond, 1),
+		sendAfter(300*time.Millisecond, 2),
+		sendAfter(10*time.Millisecond, 3), // This one sends first
+		make(chan int),
+	)
+
+	if res.Index != 2 || res.Value != 3 || res.Closed {
+		t.Errorf("OrValue() returned unexpected result: %+v (expected index 2, value 3)", res)
+	}
+}
+
+// TestOrValueClosedChannel tests that a closed channel is reported with the Closed flag
+func TestOrValueClosedChannel(t *testing.T) {
+	closed := make(chan int)
+	close(closed)
+
+	res := <-OrValue(make(chan int), closed)
+
+	if res.Index != 1 || !res.Closed || res.Value != 0 {
+		t.Errorf("OrValue() returned unexpected result: %+v (expected closed index 1)", res)
+	}
+}
+
+// TestOrValueNilInterface tests that a nil sent on an interface-typed channel is delivered as a value
+func TestOrValueNilInterface(t *testing.T) {
+	errs := make(chan error, 1)
+	errs <- nil
+
+	res := <-OrValue(make(chan error), errs)
+	if res.Index != 1 || res.Closed || res.Value != nil {
+		t.Errorf("OrValue() returned unexpected result: %+v (expected nil value from index 1)", res)
+	}
+
+	done := make(chan any, 1)
+	done <- nil
+	if res := <-OrValue(done, make(chan any)); res.Index != 0 || res.Closed || res.Value != nil {
+		t.Errorf("OrValue[any]() returned unexpected result: %+v (expected nil value from index 0)", res)
+	}
+}
+
+// TestOrValueDistinguishesTimeout tests telling a timeout apart from a completion signal
+func TestOrValueDistinguishesTimeout(t *testing.T) {
+	work := make(chan error)
+	timeout := make(chan error)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(timeout)
+	}()
+
+	res := <-OrValue(work, timeout)
+
+	if res.Index != 1 {
+		t.Errorf("OrValue() reported index %d, expected the timeout channel", res.Index)
+	}
+}
+
+// TestOrValueConsumesOnlyWinner tests that values on losing channels are not consumed
+func TestOrValueConsumesOnlyWinner(t *testing.T) {
+	a := make(chan int, 1)
+	b := make(chan int, 1)
+	a <- 1
+	b <- 2
+
+	res := <-OrValue(a, b)
+
+	// The losing channel must still hold its value
+	left := len(a) + len(b)
+	if left != 1 {
+		t.Errorf("OrValue() consumed %d values, expected exactly 1", 2-left)
+	}
+	if (res.Index == 0 && res.Value != 1) || (res.Index == 1 && res.Value != 2) {
+		t.Errorf("OrValue() returned mismatched result: %+v", res)
+	}
+}
+
+// TestOrValueClosesResult tests that the result channel is closed after the result
+func TestOrValueClosesResult(t *testing.T) {
+	results := OrValue(sendAfter(time.Millisecond, 42))
+	<-results
+
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Error("OrValue() delivered more than one result")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("OrValue() result channel was not closed")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// serverSource возвращает актуальный список адресов серверов
+type serverSource func(ctx context.Context) ([]string, error)
+
+// staticServers — список из флага -servers, через запятую
+func staticServers(list string) serverSource {
+	var addrs []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return func(context.Context) ([]string, error) { return addrs, nil }
+}
+
+// fileServers читает адреса из файла, по одному на строку. Пустые строки
+// и строки, начинающиеся с #, пропускаются. Файл перечитывается при каждом
+// обновлении, поэтому серверы можно добавлять и убирать без перезапуска.
+func fileServers(path string) serverSource {
+	return func(context.Context) ([]string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		var addrs []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			addrs = append(addrs, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		return addrs, nil
+	}
+}
+
+// srvServers берёт адреса из DNS SRV-записей name, например
+// _grep._tcp.example.com. Порядок задаётся приоритетом записей.
+func srvServers(name string) serverSource {
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		// LookupSRV перемешивает записи одного приоритета по весу; для
+		// стабильного распределения секций адреса сортируются
+		slices.SortStableFunc(records, func(a, b *net.SRV) int {
+			if a.Priority != b.Priority {
+				return int(a.Priority) - int(b.Priority)
+			}
+			return strings.Compare(a.Target, b.Target)
+		})
+		addrs := make([]string, 0, len(records))
+		for _, r := range records {
+			host := strings.TrimSuffix(r.Target, ".")
+			addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(r.Port))))
+		}
+		return addrs, nil
+	}
+}
+
+// healthService — имя сервиса в grpc.health.v1, которое проверяет клиент
+var healthService = pb.GrepService_ServiceDesc.ServiceName
+
+// probe параллельно проверяет здоровье всех серверов через grpc.health.v1
+// и помечает те, что не ответили SERVING за timeout. Серверы без сервиса
+// здоровья (Unimplemented) считаются здоровыми: это старые версии. У
+// здоровых серверов запрашивается Info, чтобы знать, сколько секций им
+// давать.
+func (p *serverPool) probe(ctx context.Context, timeout time.Duration) {
+	p.mu.Lock()
+	conns := make(map[string]*grpc.ClientConn, len(p.conns))
+	maps.Copy(conns, p.conns)
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for addr, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: healthService})
+			switch {
+			case status.Code(err) == codes.Unimplemented:
+			case err != nil:
+				log.Printf("health check of %s failed: %v", addr, err)
+				p.markFailed(addr)
+				return
+			case resp.Status != healthpb.HealthCheckResponse_SERVING:
+				log.Printf("server %s is %s", addr, resp.Status)
+				p.markFailed(addr)
+				return
+			}
+			p.markHealthy(addr)
+
+			// Без Info сервер получает defaultInflight секций
+			if info, err := pb.NewGrepServiceClient(conn).Info(ctx, &pb.InfoRequest{}); err == nil {
+				p.setCapacity(addr, info)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// refresh перечитывает список серверов из source и проверяет их здоровье.
+// Если список получить не удалось или он пуст, остаётся прежний.
+func (p *serverPool) refresh(ctx context.Context, source serverSource, timeout time.Duration) {
+	addrs, err := source(ctx)
+	if err == nil {
+		err = p.update(addrs)
+	}
+	if err != nil {
+		log.Printf("failed to refresh server list, keeping %d servers: %v", p.size(), err)
+	}
+	p.probe(ctx, timeout)
+}
+
+// watch обновляет список серверов и их здоровье каждые interval, пока не
+// отменён ctx
+func (p *serverPool) watch(ctx context.Context, source serverSource, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.refresh(ctx, source, timeout)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufconnServers запускает в памяти по серверу на каждый адрес: с
+// сервисом здоровья в заданном статусе или, для nil, без него
+func bufconnServers(t *testing.T, statuses map[string]*healthpb.HealthCheckResponse_ServingStatus) grpc.DialOption {
+	t.Helper()
+
+	listeners := make(map[string]*bufconn.Listener)
+	for addr, st := range statuses {
+		lis := bufconn.Listen(1 << 16)
+		srv := grpc.NewServer()
+		pb.RegisterGrepServiceServer(srv, pb.UnimplementedGrepServiceServer{})
+		if st != nil {
+			h := health.NewServer()
+			h.SetServingStatus(healthService, *st)
+			healthpb.RegisterHealthServer(srv, h)
+		}
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+		listeners[addr] = lis
+	}
+	return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return listeners[addr].DialContext(ctx)
+	})
+}
+
+func TestProbeMarksUnhealthy(t *testing.T) {
+	serving := healthpb.HealthCheckResponse_SERVING
+	notServing := healthpb.HealthCheckResponse_NOT_SERVING
+	dialer := bufconnServers(t, map[string]*healthpb.HealthCheckResponse_ServingStatus{
+		"up":     &serving,
+		"down":   &notServing,
+		"legacy": nil,
+	})
+
+	addrs := []string{"passthrough:///up", "passthrough:///down", "passthrough:///legacy"}
+	p, err := newServerPool(addrs, 4, dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	p.markFailed("passthrough:///up")
+	p.probe(context.Background(), time.Second)
+
+	want := map[string]bool{"passthrough:///down": true}
+	if !maps.Equal(p.unhealthy, want) {
+		t.Errorf("unhealthy = %v, want %v", p.unhealthy, want)
+	}
+	// Секции не достаются серверу, который не прошёл проверку
+	for i := range addrs {
+		addr, _, release, err := p.acquire(context.Background(), i, newPlacement(false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+		if addr == "passthrough:///down" {
+			t.Errorf("chunk %d assigned to unhealthy server", i)
+		}
+	}
+}
+
+func TestRefreshFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "servers")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := fileServers(path)
+
+	write("# pool\nhost1:1\n\n  host2:2  \n")
+	addrs, err := source(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newServerPool(addrs, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// Пустой список не заменяет прежний
+	write("# nothing\n")
+	p.refresh(context.Background(), source, time.Millisecond)
+	if !slices.Equal(p.addrs, []string{"host1:1", "host2:2"}) {
+		t.Errorf("after empty file: addrs = %v", p.addrs)
+	}
+
+	write("host2:2\nhost3:3\n")
+	p.refresh(context.Background(), source, time.Millisecond)
+	if !slices.Equal(p.addrs, []string{"host2:2", "host3:3"}) {
+		t.Errorf("addrs = %v", p.addrs)
+	}
+	if _, ok := p.clients["host1:1"]; ok {
+		t.Error("removed server still has a client")
+	}
+}
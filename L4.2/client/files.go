@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileFilter отбирает файлы по глобам --include, --exclude и
+// --exclude-dir. Глобы, как и в GNU grep, сравниваются с именем файла
+// без каталога.
+type fileFilter struct {
+	include    []string
+	exclude    []string
+	excludeDir []string
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fileFilter) skipFile(path string) bool {
+	name := filepath.Base(path)
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return true
+	}
+	return matchAny(f.exclude, name)
+}
+
+func (f fileFilter) skipDir(path string) bool {
+	return matchAny(f.excludeDir, filepath.Base(path))
+}
+
+// validate проверяет синтаксис глобов заранее, иначе ошибочный глоб просто
+// ни с чем не совпадал бы
+func (f fileFilter) validate() error {
+	for _, globs := range [][]string{f.include, f.exclude, f.excludeDir} {
+		for _, g := range globs {
+			if _, err := filepath.Match(g, ""); err != nil {
+				return fmt.Errorf("bad glob %q: %w", g, err)
+			}
+		}
+	}
+	return nil
+}
+
+// collectFiles раскрывает аргументы в список файлов для поиска в порядке
+// аргументов. С recursive каталоги обходятся рекурсивно; как и GNU grep -r,
+// символические ссылки внутри каталогов не разыменовываются, а без
+// аргументов обходится текущий каталог. Ошибки по отдельным путям
+// возвращаются списком, остальные файлы всё равно ищутся.
+func collectFiles(args []string, recursive bool, filter fileFilter) ([]string, []error) {
+	var files []string
+	var errs []error
+
+	// Файлы текущего каталога GNU grep -r печатает без префикса "./",
+	// а у явно заданного каталога сохраняет его имя как есть
+	implicit := len(args) == 0 && recursive
+	if implicit {
+		args = []string{"."}
+	}
+
+	for _, arg := range args {
+		if arg == stdinArg {
+			files = append(files, arg)
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.IsDir() {
+			if !filter.skipFile(arg) {
+				files = append(files, arg)
+			}
+			continue
+		}
+		if !recursive {
+			errs = append(errs, fmt.Errorf("%s: is a directory", arg))
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			if d.IsDir() {
+				if path != arg && filter.skipDir(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() && !filter.skipFile(path) {
+				files = append(files, walkedName(arg, path, implicit))
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return files, errs
+}
+
+// walkedName возвращает имя файла path, найденного обходом каталога root,
+// в том виде, в каком его печатает GNU grep: с root, записанным как в
+// аргументе, а не очищенным filepath.Join
+func walkedName(root, path string, implicit bool) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || implicit {
+		return path
+	}
+	if strings.HasSuffix(root, string(filepath.Separator)) {
+		return root + rel
+	}
+	return root + string(filepath.Separator) + rel
+}
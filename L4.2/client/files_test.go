@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.txt", "sub/c.log", "skip/d.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "b.txt"), filepath.Join(dir, "sub", "link.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	root := dir + "/"
+	tests := []struct {
+		name   string
+		filter fileFilter
+		want   []string
+	}{
+		{"all", fileFilter{}, []string{"a.log", "b.txt", "skip/d.log", "sub/c.log"}},
+		{"include", fileFilter{include: []string{"*.log"}}, []string{"a.log", "skip/d.log", "sub/c.log"}},
+		{"exclude", fileFilter{exclude: []string{"a.*", "*.txt"}}, []string{"skip/d.log", "sub/c.log"}},
+		{"exclude-dir", fileFilter{excludeDir: []string{"sk*"}}, []string{"a.log", "b.txt", "sub/c.log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, errs := collectFiles([]string{root}, true, tt.filter)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, root+name)
+			}
+			if !slices.Equal(files, want) {
+				t.Errorf("got %q, want %q", files, want)
+			}
+		})
+	}
+
+	// Без -r каталог — ошибка, но остальные файлы ищутся
+	files, errs := collectFiles([]string{dir, filepath.Join(dir, "a.log")}, false, fileFilter{})
+	if len(errs) != 1 || !slices.Equal(files, []string{filepath.Join(dir, "a.log")}) {
+		t.Errorf("got %q, %v", files, errs)
+	}
+}
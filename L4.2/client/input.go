@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// stdinName — аргумент, который означает стандартный ввод, и имя, под
+// которым GNU grep его печатает
+const (
+	stdinArg  = "-"
+	stdinName = "(standard input)"
+)
+
+// displayName возвращает имя входа для вывода
+func displayName(path string) string {
+	if path == stdinArg {
+		return stdinName
+	}
+	return path
+}
+
+// codec — формат сжатия входа
+type codec struct {
+	name string
+	ext  []string
+	// sniff узнаёт формат по первым sniffBytes байтам входа
+	sniff func(head []byte) bool
+	open  func(r io.Reader) (io.ReadCloser, error)
+}
+
+// sniffBytes — сколько первых байт входа нужно codec.sniff
+const sniffBytes = 10
+
+// magic возвращает codec.sniff, которому достаточно префикса prefix
+func magic(prefix ...byte) func(head []byte) bool {
+	return func(head []byte) bool {
+		return bytes.HasPrefix(head, prefix)
+	}
+}
+
+// bzip2Magic — заголовок bzip2: "BZh" и размер блока от '1' до '9', за
+// которыми идёт начало первого блока или, если поток пуст, его конец.
+// Трёх байт "BZh" мало: с них может начинаться и обычный текст.
+func bzip2Magic(head []byte) bool {
+	if len(head) < sniffBytes || !bytes.HasPrefix(head, []byte("BZh")) || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+	block := head[4:10]
+	return bytes.Equal(block, []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}) ||
+		bytes.Equal(block, []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90})
+}
+
+var codecs = []codec{
+	{
+		name:  "gzip",
+		ext:   []string{".gz", ".tgz"},
+		sniff: magic(0x1f, 0x8b),
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:  "zstd",
+		ext:   []string{".zst", ".zstd"},
+		sniff: magic(0x28, 0xb5, 0x2f, 0xfd),
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+	{
+		name:  "bzip2",
+		ext:   []string{".bz2", ".tbz2"},
+		sniff: bzip2Magic,
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+}
+
+// detectCodec выбирает формат по расширению path, а если оно ничего не
+// говорит — по первым байтам head. nil — вход не сжат.
+func detectCodec(path string, head []byte) *codec {
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := range codecs {
+		for _, e := range codecs[i].ext {
+			if ext == e {
+				return &codecs[i]
+			}
+		}
+	}
+	for i := range codecs {
+		if codecs[i].sniff(head) {
+			return &codecs[i]
+		}
+	}
+	return nil
+}
+
+// inputStream — вход, который читается только последовательно
+type inputStream struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (s *inputStream) Close() error {
+	var first error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openStream открывает вход path, если его нельзя делить на секции по
+// байтам: стандартный ввод, канал или сжатый файл, который распаковывается
+// на лету. Для несжатого обычного файла возвращается nil: его клиент
+// делит по байтам и читает секции параллельно.
+func openStream(path string) (io.ReadCloser, error) {
+	var f *os.File
+	if path == stdinArg {
+		f = os.Stdin
+	} else {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+	}
+	s := &inputStream{}
+	if f != os.Stdin {
+		s.closers = append(s.closers, f)
+	}
+
+	br := bufio.NewReaderSize(f, 64<<10)
+	// Ошибку чтения покажет следующее чтение; пустой вход просто не сжат
+	head, _ := br.Peek(sniffBytes)
+	c := detectCodec(path, head)
+
+	if c == nil {
+		info, err := f.Stat()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if path != stdinArg && info.Mode().IsRegular() {
+			s.Close()
+			return nil, nil
+		}
+		s.Reader = br
+		return s, nil
+	}
+
+	dec, err := c.open(br)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	s.Reader = dec
+	s.closers = append(s.closers, dec)
+	return s, nil
+}
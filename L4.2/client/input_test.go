@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestOpenStreamDecompresses(t *testing.T) {
+	const content = "one\ntwo gamma\nthree\n"
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zst := enc.EncodeAll([]byte(content), nil)
+
+	// В стандартной библиотеке нет сжатия bzip2, поэтому это вывод
+	// printf 'one\ntwo gamma\nthree\n' | bzip2
+	bz2 := []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x3b, 0x13, 0x26, 0xd4, 0x00, 0x00,
+		0x07, 0xd1, 0x80, 0x00, 0x10, 0x40, 0x00, 0x22, 0xc3, 0x94, 0x80, 0x20, 0x00, 0x22, 0x9a, 0x7a,
+		0x8f, 0x53, 0xf5, 0x01, 0x03, 0x40, 0xd0, 0x4a, 0x0f, 0xb4, 0x49, 0x4c, 0x2e, 0xae, 0x34, 0xf0,
+		0x5d, 0xc9, 0x14, 0xe1, 0x42, 0x40, 0xec, 0x4c, 0x9b, 0x50,
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"log.gz":      gz.Bytes(),
+		"log.zst":     zst,
+		"rotated.1":   zst, // формат по первым байтам
+		"archive.dat": gz.Bytes(),
+		"log.bz2":     bz2,
+		"rotated.2":   bz2,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		in, err := openStream(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if in == nil {
+			t.Fatalf("%s: compressed file is not streamed", name)
+		}
+		got, err := io.ReadAll(in)
+		in.Close()
+		if err != nil || string(got) != content {
+			t.Errorf("%s: got %q, %v", name, got, err)
+		}
+	}
+
+	// Несжатый обычный файл делится по байтам, а не читается потоком, даже
+	// если начинается с "BZh", как bzip2
+	for name, text := range map[string]string{
+		"plain.txt": content,
+		"notes":     "BZh9 is not a bzip2 header\n",
+		"short":     "BZh",
+	} {
+		plain := filepath.Join(dir, name)
+		if err := os.WriteFile(plain, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if in, err := openStream(plain); in != nil || err != nil {
+			t.Errorf("%s: got %v, %v", name, in, err)
+		}
+	}
+}
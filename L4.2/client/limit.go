@@ -0,0 +1,67 @@
+package main
+
+import (
+	pb "grpc-grep/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// matchLimit соблюдает -m по всему файлу. Каждый сервер ограничивает
+// только свою часть, поэтому клиент берёт ответы частей по порядку и
+// отбрасывает всё после max-й выбранной строки, кроме её контекста -A.
+type matchLimit struct {
+	max   int
+	after int
+}
+
+// selectedLines возвращает число выбранных строк в ответе части
+func selectedLines(resp *pb.GrepResponse, countOnly bool) int {
+	if countOnly {
+		return int(resp.Count)
+	}
+	n := 0
+	for _, m := range resp.Matches {
+		if !m.Context {
+			n++
+		}
+	}
+	return n
+}
+
+// apply возвращает совпадения упорядоченных ответов частей с учётом
+// лимита. Как и GNU grep, после max-й строки печатается ещё after строк
+// контекста, даже если среди них есть совпадения: такие строки
+// становятся строками контекста и не подсвечиваются.
+func (l matchLimit) apply(resps []*pb.GrepResponse) []*pb.Match {
+	var out []*pb.Match
+	remaining := l.max
+	last := int64(-1) // номер последней выбранной строки
+
+	for _, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		for _, m := range resp.Matches {
+			if remaining > 0 {
+				if !m.Context {
+					remaining--
+					last = m.LineNumber
+				}
+				out = append(out, m)
+				continue
+			}
+
+			// Лимит исчерпан: остаётся только хвост -A последней строки
+			if m.LineNumber > last+int64(l.after) {
+				return out
+			}
+			if !m.Context {
+				m = proto.CloneOf(m)
+				m.Context = true
+				m.Submatches = nil
+			}
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"grpc-grep/internal/render"
+	pb "grpc-grep/proto"
+)
+
+// chunkResponse собирает ответ части из пар "номер строки, контекст"
+func chunkResponse(lines ...any) *pb.GrepResponse {
+	resp := &pb.GrepResponse{}
+	for i := 0; i < len(lines); i += 2 {
+		resp.Matches = append(resp.Matches, &pb.Match{
+			LineNumber: int64(lines[i].(int)),
+			Context:    lines[i+1].(bool),
+			Text:       []byte("x"),
+		})
+	}
+	return resp
+}
+
+// plain печатает совпадения с -n
+func plain(matches []*pb.Match) []string {
+	var out []string
+	for _, m := range matches {
+		out = append(out, render.Plain(m, render.Options{LineNum: true})...)
+	}
+	return out
+}
+
+func TestMatchLimitAcrossChunks(t *testing.T) {
+	// Каждая часть ограничена -m 2 сама по себе; вторая часть начинается
+	// с хвоста -A совпадения из первой
+	resps := []*pb.GrepResponse{
+		chunkResponse(1, false, 2, true, 5, false),
+		chunkResponse(6, false, 7, true, 8, true, 9, false),
+	}
+
+	limit := matchLimit{max: 2, after: 2}
+	want := []string{"1:x", "2-x", "5:x", "6-x", "7-x"}
+	if got := plain(limit.apply(resps)); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if resps[1].Matches[0].Context {
+		t.Error("apply modified the response")
+	}
+
+	limit = matchLimit{max: 3}
+	want = []string{"1:x", "2-x", "5:x", "6:x"}
+	if got := plain(limit.apply(resps)); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
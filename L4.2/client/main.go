@@ -1,20 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strings"
-	"sync"
+	"slices"
 	"time"
 
+	"grpc-grep/internal/render"
 	pb "grpc-grep/proto"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
 )
 
 type result struct {
@@ -31,159 +30,342 @@ func main() {
 	invert := flag.Bool("v", false, "Invert match")
 	fixed := flag.Bool("F", false, "Fixed strings (no regex)")
 	lineNum := flag.Bool("n", false, "Show line numbers")
+	word := flag.Bool("w", false, "Match only whole words")
+	wholeLine := flag.Bool("x", false, "Match only whole lines")
+	onlyMatching := flag.Bool("o", false, "Print only the matched parts of lines")
+	maxCount := flag.Int("m", -1, "Stop after NUM selected lines in each file")
+	jsonOut := flag.Bool("json", false, "Print one JSON object per line with line numbers, byte offsets and match spans")
+	color := colorMode("auto")
+	flag.Var(&color, "color", "Highlight matches, line numbers and separators: auto, always or never")
+	groupSep := flag.String("group-separator", "--", "Line printed between groups of context lines")
+	noGroupSep := flag.Bool("no-group-separator", false, "Do not print a separator between groups of context lines")
+	recursive := flag.Bool("r", false, "Search directories recursively")
+	var include, exclude, excludeDir stringList
+	flag.Var(&include, "include", "Search only files whose base name matches GLOB; may be repeated")
+	flag.Var(&exclude, "exclude", "Skip files whose base name matches GLOB; may be repeated")
+	flag.Var(&excludeDir, "exclude-dir", "Skip directories whose base name matches GLOB when recursing; may be repeated")
+	withFileName := flag.Bool("H", false, "Print the file name for each match")
+	noFileName := flag.Bool("h", false, "Never print file names")
+	listFiles := flag.Bool("l", false, "Print only names of files with matches")
+	listMissing := flag.Bool("L", false, "Print only names of files without matches")
 	serversFlag := flag.String("servers", "localhost:50053", "Comma-separated list of server addresses")
+	serversFile := flag.String("servers-file", "", "Read server addresses from file, one per line; re-read every -refresh")
+	serversSRV := flag.String("servers-srv", "", "Resolve server addresses from DNS SRV records of this name, e.g. _grep._tcp.example.com")
+	refresh := flag.Duration("refresh", 30*time.Second, "How often to reload the server list and re-check server health; 0 disables")
+	inflight := flag.Int("inflight", 4, "Maximum chunks processed by one server at a time; the actual limit follows the server's CPU count and load")
+	healthTimeout := flag.Duration("health-timeout", time.Second, "Time limit for one server health check")
+	retries := flag.Int("retries", 3, "Retries per chunk on other servers after a failure")
+	backoffFlag := flag.Duration("backoff", 200*time.Millisecond, "Delay before the first retry, doubled on each next one")
+	replicas := flag.Int("replicas", 1, "Replication factor: send each chunk to N servers and accept the majority answer")
+	remote := flag.Bool("remote", false, "Read the files on the servers (paths relative to their -root) instead of sending lines")
+	payload := flag.String("payload", "block", "How lines are sent to servers: block (raw bytes) or lines (one UTF-8 string per line)")
+	compress := flag.String("compress", "none", "Compress requests and responses: gzip or none")
+	timeout := flag.Duration("timeout", 30*time.Second, "Time limit for the whole search")
+	var patterns stringList
+	flag.Var(&patterns, "e", "Pattern to search for; may be repeated, a line matches if any pattern matches")
+	patternFile := flag.String("f", "", "Read patterns from file, one per line")
 
 	flag.Parse()
 
+	// Как и GNU grep, разделители групп печатаются, только если задан
+	// контекст, пусть даже нулевой
+	contextSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "A" || f.Name == "B" {
+			contextSet = true
+		}
+	})
+	useColor := !*jsonOut && color.enabled(os.Stdout)
+
+	if *patternFile != "" {
+		fromFile, err := readPatternFile(*patternFile)
+		if err != nil {
+			log.Fatalf("failed to read patterns: %v", err)
+		}
+		patterns = append(patterns, fromFile...)
+	}
+
+	// Без -e и -f шаблон — первый позиционный аргумент
 	args := flag.Args()
-	if len(args) < 2 {
-		fmt.Println("Usage: client [flags] pattern file")
+	if len(patterns) == 0 && *patternFile == "" && len(args) > 0 {
+		patterns = stringList{args[0]}
+		args = args[1:]
+	}
+	if len(patterns) == 0 && *patternFile == "" {
+		fmt.Println("Usage: client [flags] pattern [file...]")
+		fmt.Println("       client [flags] -e pattern [-e pattern ...] [-f file] [file...]")
+		fmt.Println("       client [flags] -r pattern [dir...]")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
+	// Как и GNU grep, без файлов читаем stdin, а с -r — текущий каталог,
+	// который обходит collectFiles
+	if len(args) == 0 && !*recursive {
+		args = []string{stdinArg}
+	}
+	if len(patterns) == 0 {
+		log.Fatal("no patterns given: pattern file is empty")
+	}
 
-	pattern := args[0]
-	filePath := args[1]
+	filter := fileFilter{include: include, exclude: exclude, excludeDir: excludeDir}
+	if err := filter.validate(); err != nil {
+		log.Fatal(err)
+	}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		log.Fatalf("failed to open file: %v", err)
+	// Удалённые пути клиент не видит: они проверяются на серверах
+	files := args
+	hadErrors := false
+	if *remote {
+		if *recursive {
+			log.Fatal("-r is not supported with -remote: servers do not list directories")
+		}
+		if slices.Contains(args, stdinArg) {
+			log.Fatal("stdin is not supported with -remote: servers read files from their own disk")
+		}
+	} else {
+		var errs []error
+		files, errs = collectFiles(args, *recursive, filter)
+		for _, err := range errs {
+			log.Print(err)
+		}
+		hadErrors = len(errs) > 0
 	}
-	defer file.Close()
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var source serverSource
+	switch {
+	case *serversFile != "" && *serversSRV != "":
+		log.Fatal("-servers-file and -servers-srv are mutually exclusive")
+	case *serversFile != "":
+		source = fileServers(*serversFile)
+	case *serversSRV != "":
+		source = srvServers(*serversSRV)
+	default:
+		source = staticServers(*serversFlag)
 	}
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("failed to read file: %v", err)
+	serverAddrs, err := source(ctx)
+	if err != nil {
+		log.Fatalf("failed to get server list: %v", err)
 	}
-
-	serverAddrs := strings.Split(*serversFlag, ",")
 	numServers := len(serverAddrs)
 	if numServers == 0 {
 		log.Fatal("no servers specified")
 	}
 
-	chunks := splitToChunks(lines, numServers)
-
-	var wg sync.WaitGroup
-	results := make(chan result, numServers)
+	var dialOpts []grpc.DialOption
+	switch *compress {
+	case "gzip":
+		// Сервер отвечает тем же сжатием, каким сжат запрос
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	case "none":
+	default:
+		log.Fatalf("unknown -compress %q: want gzip or none", *compress)
+	}
+	if *payload != "block" && *payload != "lines" {
+		log.Fatalf("unknown -payload %q: want block or lines", *payload)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
+	pool, err := newServerPool(serverAddrs, *inflight, dialOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
 
-	for i, addr := range serverAddrs {
-		wg.Add(1)
-		currentOffset := 0
-		for k := range i {
-			currentOffset += len(chunks[k])
-		}
+	// Мёртвые серверы отсеиваются до раздачи секций, а не после первой
+	// упавшей секции; дальше список и здоровье обновляются в фоне
+	pool.probe(ctx, *healthTimeout)
+	if *refresh > 0 {
+		go pool.watch(ctx, source, *refresh, *healthTimeout)
+	}
 
-		go func(index int, address string, chunk []string, offset int) {
-			defer wg.Done()
+	policy := retryPolicy{attempts: *retries + 1, backoff: *backoffFlag}
 
-			if len(chunk) == 0 {
-				results <- result{rank: index, err: nil, resp: &pb.GrepResponse{}}
-				return
-			}
+	// Реплик не может быть больше, чем серверов
+	if *replicas > numServers {
+		log.Printf("replication factor %d exceeds %d servers, using %d", *replicas, numServers, numServers)
+		*replicas = numServers
+	}
 
-			conn, err := grpc.NewClient(
-				address,
-				grpc.WithTransportCredentials(insecure.NewCredentials()),
-			)
-			if err != nil {
-				log.Printf("failed to connect to %s: %v", address, err)
-				results <- result{err: err}
-				return
-			}
-			defer conn.Close()
-
-			client := pb.NewGrepServiceClient(conn)
-
-			resp, err := client.Grep(ctx, &pb.GrepRequest{
-				Lines:      chunk,
-				Pattern:    pattern,
-				After:      int32(*after),
-				Before:     int32(*before),
-				CountOnly:  *countOnly,
-				Ignore:     *ignore,
-				Invert:     *invert,
-				Fixed:      *fixed,
-				LineNum:    *lineNum,
-				LineOffset: int32(offset),
-			})
-			results <- result{rank: i, resp: resp, err: err}
-		}(i, addr, chunks[i], currentOffset)
-	}
-
-	// Кворум (N/2 + 1)
-	quorum := (numServers / 2) + 1
-	success := 0
-	failed := 0
-	collected := make([]*pb.GrepResponse, numServers) // Fixed size for ordering
-
-	// Сбор результатов по кворуму
-	responsesReceived := 0
-	for responsesReceived < numServers {
-		res := <-results
-		responsesReceived++
+	out := newPrinter(os.Stdout, *jsonOut, render.Options{LineNum: *lineNum, OnlyMatching: *onlyMatching, Color: useColor})
+	// Имена файлов печатаются, если файлов может быть несколько
+	out.withNames = (len(files) > 1 || *recursive || *withFileName) && !*noFileName
+	if contextSet && !*noGroupSep {
+		out.groups, out.separator = true, *groupSep
+		out.before, out.after = *before, *after
+	}
 
-		if res.err != nil {
-			log.Printf("server error: %v", res.err)
-			failed++
-		} else {
-			success++
-			collected[res.rank] = res.resp
+	// -m 0, как и в GNU grep, не выбирает ни одной строки
+	if *maxCount == 0 && !*listMissing {
+		if *countOnly {
+			out.count(0)
+			out.flush()
 		}
-
+		return
 	}
 
-	if success < quorum {
-		log.Fatalf("Quorum not reached: success=%d, failed=%d, quorum=%d", success, failed, quorum)
+	// Для -l и -L серверу достаточно найти первую выбранную строку
+	listing := *listFiles || *listMissing
+	countMode := *countOnly || listing
+	serverMax := max(*maxCount, 0)
+	if listing {
+		serverMax = 1
 	}
 
-	if *countOnly {
-		total := 0
-		for _, resp := range collected {
-			if resp == nil {
-				continue
+	s := &searcher{
+		pool:     pool,
+		policy:   policy,
+		replicas: *replicas,
+		remote:   *remote,
+		block:    *payload == "block",
+		query: func() *pb.GrepRequest {
+			return &pb.GrepRequest{
+				Patterns:     patterns,
+				After:        int32(*after),
+				Before:       int32(*before),
+				CountOnly:    countMode,
+				Ignore:       *ignore,
+				Invert:       *invert,
+				Fixed:        *fixed,
+				LineNum:      *lineNum,
+				Word:         *word,
+				WholeLine:    *wholeLine,
+				OnlyMatching: *onlyMatching,
+				MaxCount:     int32(serverMax),
+				Submatches:   *jsonOut || useColor,
 			}
-			total += int(resp.Count)
+		},
+		maxCount:  serverMax,
+		countOnly: countMode,
+		anyMatch:  listing,
+		slots:     make(chan struct{}, 2*numServers*max(*inflight, 1)),
+	}
+	if !countMode {
+		s.after = *after
+	}
+
+	// Файлы ищутся параллельно, но печатаются строго по порядку. Файл
+	// освобождает место в fileSlots только после печати, поэтому в памяти
+	// одновременно лежат результаты не более чем fileSlots файлов.
+	fileSlots := make(chan struct{}, 2*numServers)
+	pending := make([]chan fileResult, len(files))
+	for i := range pending {
+		pending[i] = make(chan fileResult, 1)
+	}
+	go func() {
+		for i, path := range files {
+			fileSlots <- struct{}{}
+			go func() { pending[i] <- s.searchFile(ctx, path) }()
 		}
-		fmt.Printf("Total Count (Quorum %d/%d): %d\n", success, numServers, total)
-	} else {
-		for _, resp := range collected {
-			if resp == nil {
+	}()
+
+	failed, total := 0, 0
+	for i, path := range files {
+		res := <-pending[i]
+		<-fileSlots
+		if res.err != nil {
+			log.Printf("%s: %v", displayName(path), res.err)
+			hadErrors = true
+			// Строки, найденные до ошибки чтения, всё же печатаются, как в
+			// GNU grep; неполные -c, -l и -L были бы неверны
+			if *countOnly || listing || len(res.resps) == 0 {
 				continue
 			}
-			for _, line := range resp.Output {
-				fmt.Println(line)
-			}
+		}
+		failed += res.failed
+
+		if err := printFile(out, path, res, fileOutput{
+			countOnly:   *countOnly,
+			listFiles:   *listFiles,
+			listMissing: *listMissing,
+			maxCount:    max(*maxCount, 0),
+			after:       *after,
+			total:       &total,
+		}); err != nil {
+			log.Fatal(err)
 		}
 	}
-}
 
-func splitToChunks(lines []string, n int) [][]string {
-	if n <= 0 {
-		return nil
+	// Без имён файлов -c печатает один общий итог, если он полный
+	if *countOnly && !out.withNames && failed == 0 {
+		if err := out.count(total); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := out.flush(); err != nil {
+		log.Fatal(err)
 	}
-	total := len(lines)
-	if total == 0 {
-		return make([][]string, n)
+
+	if failed > 0 {
+		log.Fatalf("incomplete result: %d chunks could not be processed", failed)
+	}
+	if hadErrors {
+		os.Exit(2)
+	}
+}
+
+// fileOutput — режим печати результата одного файла
+type fileOutput struct {
+	countOnly   bool
+	listFiles   bool
+	listMissing bool
+	maxCount    int // -m на файл; 0 — без ограничения
+	after       int
+	total       *int // общий итог -c, если имена файлов не печатаются
+}
+
+// printFile печатает результат поиска по файлу path
+func printFile(out *printer, path string, res fileResult, mode fileOutput) error {
+	out.startFile(displayName(path))
+
+	selected := 0
+	for _, resp := range res.resps {
+		if resp != nil {
+			selected += selectedLines(resp, true)
+		}
 	}
 
-	chunkSize := (total + n - 1) / n
-	chunks := make([][]string, n)
+	switch {
+	case mode.listFiles:
+		if selected > 0 {
+			return out.fileName()
+		}
+		return nil
+	case mode.listMissing:
+		if selected == 0 && res.failed == 0 {
+			return out.fileName()
+		}
+		return nil
+	case mode.countOnly:
+		// Как и -L: без потерянных секций число неизвестно
+		if res.failed > 0 {
+			return nil
+		}
+		if mode.maxCount > 0 {
+			selected = min(selected, mode.maxCount)
+		}
+		if !out.withNames {
+			*mode.total += selected
+			return nil
+		}
+		return out.count(selected)
+	}
 
-	for i := 0; i < n; i++ {
-		start := i * chunkSize
-		if start >= total {
-			break
+	var matches []*pb.Match
+	if mode.maxCount > 0 {
+		matches = matchLimit{max: mode.maxCount, after: mode.after}.apply(res.resps[:res.ready])
+	} else {
+		for _, resp := range res.resps {
+			if resp != nil {
+				matches = append(matches, resp.Matches...)
+			}
+		}
+	}
+	for _, m := range matches {
+		if err := out.match(m); err != nil {
+			return err
 		}
-		end := min(start + chunkSize, total)
-		chunks[i] = lines[start:end]
 	}
-	return chunks
+	return nil
 }
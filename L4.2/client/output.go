@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"grpc-grep/internal/render"
+	pb "grpc-grep/proto"
+)
+
+// colorMode — значение флага --color. Флаг можно передать и без значения,
+// как в GNU grep: --color означает auto.
+type colorMode string
+
+func (c *colorMode) String() string { return string(*c) }
+
+func (c *colorMode) Set(v string) error {
+	switch v {
+	case "true":
+		*c = "auto"
+	case "auto", "always", "never":
+		*c = colorMode(v)
+	default:
+		return fmt.Errorf("want auto, always or never, got %q", v)
+	}
+	return nil
+}
+
+func (c *colorMode) IsBoolFlag() bool { return true }
+
+// enabled решает, подсвечивать ли вывод в f: в режиме auto — только если
+// это терминал, который понимает цвета
+func (c colorMode) enabled(f *os.File) bool {
+	switch c {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printer печатает результат поиска: строки в формате GNU grep или, с
+// --json, по одному JSON-объекту на строку
+type printer struct {
+	w    *bufio.Writer
+	json bool
+	opts render.Options
+
+	// withNames — печатать имя файла перед строками (-H), file — текущий файл
+	withNames bool
+	file      string
+
+	// С groups группы контекста разделяются строкой separator, как "--" в
+	// GNU grep. before и after нужны, чтобы с -o, где строки контекста не
+	// печатаются, найти границы групп так же, как GNU.
+	groups        bool
+	separator     string
+	before, after int
+	last          int64 // номер последней напечатанной строки файла, -1 — ещё ни одной
+	fileBreak     bool  // в предыдущих файлах уже что-то напечатано
+}
+
+func newPrinter(w io.Writer, asJSON bool, opts render.Options) *printer {
+	return &printer{w: bufio.NewWriter(w), json: asJSON, opts: opts, last: -1}
+}
+
+// startFile переключает вывод на следующий файл. Как и GNU grep, группы
+// разных файлов тоже разделяются separator.
+func (p *printer) startFile(path string) {
+	p.file = path
+	p.opts.FileName = ""
+	if p.withNames {
+		p.opts.FileName = path
+	}
+	if p.last >= 0 {
+		p.fileBreak = true
+	}
+	p.last = -1
+}
+
+func (p *printer) match(m *pb.Match) error {
+	if p.json {
+		data, err := render.JSON(p.file, m)
+		if err != nil {
+			return err
+		}
+		return p.line(string(data))
+	}
+
+	lines := render.Plain(m, p.opts)
+	if len(lines) == 0 {
+		return nil
+	}
+	if p.groups && (p.fileBreak || p.last >= 0 && p.newGroup(m.LineNumber)) {
+		if err := p.line(render.Separator(p.separator, p.opts)); err != nil {
+			return err
+		}
+	}
+	p.last = m.LineNumber
+	p.fileBreak = false
+
+	for _, line := range lines {
+		if err := p.line(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newGroup сообщает, что строка line не примыкает к предыдущей группе
+// контекста
+func (p *printer) newGroup(line int64) bool {
+	start, end := line, p.last
+	if p.opts.OnlyMatching {
+		start -= int64(p.before)
+		end += int64(p.after)
+	}
+	return start > end+1
+}
+
+// count печатает итог -c: для текущего файла с -H или общий без него
+func (p *printer) count(n int) error {
+	name := ""
+	if p.withNames {
+		name = p.file
+	}
+	if p.json {
+		data, err := render.Summary(name, n)
+		if err != nil {
+			return err
+		}
+		return p.line(string(data))
+	}
+	if name == "" {
+		_, err := fmt.Fprintf(p.w, "Total Count: %d\n", n)
+		return err
+	}
+	return p.line(render.Count(name, n, p.opts))
+}
+
+// fileName печатает имя текущего файла для -l и -L
+func (p *printer) fileName() error {
+	if p.json {
+		data, err := render.File(p.file)
+		if err != nil {
+			return err
+		}
+		return p.line(string(data))
+	}
+	return p.line(render.FileName(p.file, p.opts))
+}
+
+func (p *printer) line(s string) error {
+	if _, err := p.w.WriteString(s); err != nil {
+		return err
+	}
+	return p.w.WriteByte('\n')
+}
+
+func (p *printer) flush() error {
+	return p.w.Flush()
+}
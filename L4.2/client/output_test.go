@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grpc-grep/internal/render"
+	pb "grpc-grep/proto"
+)
+
+func TestPrinterMatchesGNU(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]string{
+		// С -A 1 -B 1 группы строк 1–3 и 4–6 примыкают друг к другу, а
+		// 9–12 и 10–14 перекрываются
+		"a.txt": {"x", "ERROR", "x", "x", "ERROR", "x", "x", "x", "x", "ERROR", "ERROR x", "x", "x", "x", "x", "x", "ERROR"},
+		"b.txt": {"ERROR first", "y", "y", "y ERROR and ERROR", "y", "y", "y", "y", "ERROR"},
+		"c.txt": {"nothing", "here"},
+		"d.txt": {"z", "z", "z", "last ERROR"},
+	}
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(files[name], "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	p := newFakeGrepPool(fakeGrepServer{})
+	for _, f := range []grepFlags{
+		{},
+		{after: 1, before: 1},
+		{after: 2},
+		{before: 2},
+		{after: 1, before: 2},
+		{after: 3, before: 3},
+		{onlyMatching: true},
+		{before: 1, onlyMatching: true},
+		{before: 3, onlyMatching: true},
+		{after: 1, before: 2, onlyMatching: true},
+	} {
+		// Каждый файл по отдельности и все вместе: между файлами GNU grep
+		// тоже печатает разделитель групп
+		for _, set := range append([][]string{paths}, [][]string{paths[:1], paths[1:2]}...) {
+			got := searchOutput(t, p, "ERROR", f, set...)
+			want := gnuGrep(t, append(f.args(), append([]string{"ERROR"}, set...)...)...)
+			if got != want {
+				t.Errorf("%v on %d files: got\n%s\nwant\n%s", f.args(), len(set), got, want)
+			}
+		}
+	}
+}
+
+func TestPrintFileSkipsIncompleteCount(t *testing.T) {
+	// В первой секции совпадений нет, вторая потеряна: ни -c, ни -L не
+	// знают, сколько строк в файле совпало
+	res := fileResult{
+		resps:  []*pb.GrepResponse{{}, nil},
+		ready:  1,
+		failed: 1,
+	}
+	for _, mode := range []fileOutput{{countOnly: true}, {listMissing: true}} {
+		var buf bytes.Buffer
+		out := newPrinter(&buf, false, render.Options{})
+		out.withNames = true
+		if err := printFile(out, "log.txt", res, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() > 0 {
+			t.Errorf("%+v: printed %q for an incomplete file", mode, buf.String())
+		}
+	}
+}
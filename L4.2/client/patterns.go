@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"grpc-grep/internal/lineio"
+)
+
+// stringList — повторяемый строковый флаг: -e, --include, --exclude
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// readPatternFile читает шаблоны для -f, по одному на строку. Как и в GNU
+// grep, пустая строка файла — пустой шаблон, совпадающий с любой строкой.
+func readPatternFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := lineio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, lineio.TrimCR(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return patterns, nil
+}
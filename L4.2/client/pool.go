@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// defaultInflight — сколько секций держать на сервере, пока он не
+// сообщил свои возможности через Info
+const defaultInflight = 2
+
+// serverPool держит соединения со всеми серверами и помнит, какие из них
+// недавно отвечали ошибкой или не прошли проверку здоровья. Список
+// серверов может меняться на ходу (update), поэтому addrs и clients
+// читаются только под mu.
+//
+// Пул же распределяет секции: на сервере одновременно не больше
+// limits[addr] секций, и освободившийся сервер сразу берёт следующую.
+// Быстрые серверы поэтому обрабатывают больше секций, чем медленные, и
+// самый медленный узел не задерживает весь поиск.
+type serverPool struct {
+	mu        sync.Mutex
+	addrs     []string
+	clients   map[string]pb.GrepServiceClient
+	conns     map[string]*grpc.ClientConn
+	unhealthy map[string]bool
+
+	maxInflight int
+	inflight    map[string]int
+	limits      map[string]int
+	freed       chan struct{} // закрывается, когда на каком-то сервере появилось место
+
+	// busyUntil — до какого момента сервер, отклонивший запрос по своему
+	// лимиту, не получает секций
+	busyUntil map[string]time.Time
+	// messages — лимиты сервера на одно сообщение из Info
+	messages map[string]messageLimits
+
+	dialOpts []grpc.DialOption
+}
+
+func newServerPool(addrs []string, maxInflight int, opts ...grpc.DialOption) (*serverPool, error) {
+	p := &serverPool{
+		clients:     make(map[string]pb.GrepServiceClient, len(addrs)),
+		conns:       make(map[string]*grpc.ClientConn, len(addrs)),
+		unhealthy:   make(map[string]bool),
+		maxInflight: max(maxInflight, 1),
+		inflight:    make(map[string]int, len(addrs)),
+		limits:      make(map[string]int, len(addrs)),
+		freed:       make(chan struct{}),
+		busyUntil:   make(map[string]time.Time),
+		messages:    make(map[string]messageLimits),
+		dialOpts:    append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...),
+	}
+	if err := p.update(addrs); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// update заменяет список серверов: к новым адресам открываются соединения,
+// соединения с исчезнувшими закрываются. Запросы, которые ещё шли на
+// удалённый сервер, обрываются и повторяются на других.
+func (p *serverPool) update(addrs []string) error {
+	if len(addrs) == 0 {
+		return errors.New("empty server list")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keep := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		keep[addr] = true
+		if p.clients[addr] != nil {
+			continue
+		}
+		conn, err := grpc.NewClient(addr, p.dialOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", addr, err)
+		}
+		p.conns[addr] = conn
+		p.clients[addr] = pb.NewGrepServiceClient(conn)
+	}
+	for addr, conn := range p.conns {
+		if !keep[addr] {
+			conn.Close()
+			delete(p.conns, addr)
+			delete(p.clients, addr)
+			delete(p.unhealthy, addr)
+			delete(p.inflight, addr)
+			delete(p.limits, addr)
+			delete(p.busyUntil, addr)
+			delete(p.messages, addr)
+		}
+	}
+	p.addrs = slices.Clone(addrs)
+	p.wakeLocked()
+	return nil
+}
+
+// size возвращает текущее число серверов
+func (p *serverPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.addrs)
+}
+
+func (p *serverPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+}
+
+// placement — серверы, задействованные для одной секции. Его делят
+// реплики секции, поэтому меняется он только под serverPool.mu.
+type placement struct {
+	tried map[string]bool // серверы, на которых секция уже была
+	// voters — серверы, ответ которых реплика уже получила или ещё ждёт.
+	// Один сервер не голосует за секцию дважды, поэтому их не берёт ни
+	// одна реплика, даже когда других серверов нет. nil — секция без реплик.
+	voters map[string]bool
+}
+
+func newPlacement(replicated bool) *placement {
+	pl := &placement{tried: make(map[string]bool)}
+	if replicated {
+		pl.voters = make(map[string]bool)
+	}
+	return pl
+}
+
+// errNoVoter — все серверы уже голосуют за эту секцию, и реплике некуда идти
+var errNoVoter = errors.New("no server left that has not answered for this chunk")
+
+// acquire выбирает сервер для очередной попытки секции и занимает на нём
+// место; release освобождает его. Берётся наименее загруженный здоровый
+// сервер, на котором секция ещё не была, а при равной загрузке — первый,
+// начиная с предпочтительного. Если здоровых не осталось, метки
+// сбрасываются и серверы пробуются заново. Если у всех подходящих серверов
+// места заняты или они попросили подождать, acquire ждёт, пока
+// какое-нибудь освободится. Реплика получает только сервер, который ещё не
+// голосует за секцию, а если таких нет — errNoVoter.
+func (p *serverPool) acquire(
+	ctx context.Context,
+	preferred int,
+	pl *placement,
+) (addr string, client pb.GrepServiceClient, release func(), err error) {
+	for {
+		p.mu.Lock()
+		addr, found := p.pickLocked(preferred, pl)
+		if addr != "" {
+			pl.tried[addr] = true
+			if pl.voters != nil {
+				pl.voters[addr] = true
+			}
+			p.inflight[addr]++
+			client := p.clients[addr]
+			p.mu.Unlock()
+			return addr, client, func() { p.release(addr) }, nil
+		}
+		if !found {
+			p.mu.Unlock()
+			return "", nil, nil, errNoVoter
+		}
+		freed := p.freed
+		wait := p.busyWaitLocked()
+		p.mu.Unlock()
+
+		// Ожидание по busyUntil заканчивается само, без release
+		var expired <-chan time.Time
+		var timer *time.Timer
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-freed:
+		case <-expired:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return "", nil, nil, ctx.Err()
+		}
+	}
+}
+
+// busyWaitLocked возвращает, через сколько истечёт ближайшая пауза из
+// busyUntil, или 0, если пауз нет
+func (p *serverPool) busyWaitLocked() time.Duration {
+	var wait time.Duration
+	now := time.Now()
+	for _, until := range p.busyUntil {
+		if d := until.Sub(now); d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// backOff не даёт серверу секций в течение delay: он отклонил запрос,
+// потому что занят
+func (p *serverPool) backOff(addr string, delay time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.clients[addr]; ok {
+		p.busyUntil[addr] = time.Now().Add(delay)
+	}
+}
+
+func (p *serverPool) release(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Сервер мог пропасть из списка, пока шёл запрос
+	if p.inflight[addr] > 0 {
+		p.inflight[addr]--
+	}
+	p.wakeLocked()
+}
+
+// wakeLocked будит секции, которые ждут свободного места
+func (p *serverPool) wakeLocked() {
+	close(p.freed)
+	p.freed = make(chan struct{})
+}
+
+// pickLocked возвращает сервер для секции или "", если все подходящие
+// серверы заняты. found сообщает, что подходящий сервер вообще есть:
+// иначе все серверы уже голосуют за секцию.
+func (p *serverPool) pickLocked(preferred int, pl *placement) (addr string, found bool) {
+	for pass := 0; pass < 2; pass++ {
+		if addr, found := p.leastLoadedLocked(preferred, func(addr string) bool {
+			return !p.unhealthy[addr] && !pl.tried[addr] && !pl.voters[addr]
+		}); found {
+			return addr, true
+		}
+		if addr, found := p.leastLoadedLocked(preferred, func(addr string) bool {
+			return !p.unhealthy[addr] && !pl.voters[addr]
+		}); found {
+			return addr, true
+		}
+		clear(p.unhealthy)
+	}
+	return "", false
+}
+
+// dropVoter снимает голос сервера addr за секцию: его ответа не будет
+func (p *serverPool) dropVoter(pl *placement, addr string) {
+	p.mu.Lock()
+	delete(pl.voters, addr)
+	p.mu.Unlock()
+}
+
+// leastLoadedLocked ищет среди серверов, подходящих под ok, наименее
+// загруженный, у которого есть свободное место и который не просил
+// подождать. found сообщает, нашёлся ли хоть один подходящий сервер, пусть
+// и занятый.
+func (p *serverPool) leastLoadedLocked(preferred int, ok func(addr string) bool) (addr string, found bool) {
+	bestLoad := 1.0
+	now := time.Now()
+	for k := range p.addrs {
+		candidate := p.addrs[(preferred+k)%len(p.addrs)]
+		if !ok(candidate) {
+			continue
+		}
+		found = true
+		if now.Before(p.busyUntil[candidate]) {
+			continue
+		}
+		if load := float64(p.inflight[candidate]) / float64(p.limitLocked(candidate)); load < bestLoad {
+			addr, bestLoad = candidate, load
+		}
+	}
+	return addr, found
+}
+
+func (p *serverPool) limitLocked(addr string) int {
+	if limit, ok := p.limits[addr]; ok {
+		return limit
+	}
+	return min(defaultInflight, p.maxInflight)
+}
+
+// setCapacity задаёт, сколько секций держать на сервере, по его ответу
+// на Info: по одной на каждый процессор, не занятый посторонней работой,
+// но не меньше одной и не больше maxInflight. Свои запросы поиска сервер
+// считает в active_requests; каждый из них занимает примерно один
+// процессор, поэтому из загрузки они вычитаются. Заодно запоминаются
+// лимиты сервера на одно сообщение.
+func (p *serverPool) setCapacity(addr string, info *pb.InfoResponse) {
+	external := max(info.Load1-float64(info.ActiveRequests), 0)
+	free := int(math.Round(float64(info.CpuCount) - external))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.clients[addr]; !ok {
+		return
+	}
+	p.limits[addr] = min(max(free, 1), p.maxInflight)
+	p.messages[addr] = messageLimits{lines: int(info.MaxRequestLines), bytes: int(info.MaxRequestBytes)}
+	p.wakeLocked()
+}
+
+// messageLimits возвращает лимиты сервера addr на одно сообщение; пока
+// сервер не ответил на Info, они неизвестны и равны нулю
+func (p *serverPool) messageLimits(addr string) messageLimits {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.messages[addr]
+}
+
+func (p *serverPool) markFailed(addr string) {
+	p.mu.Lock()
+	p.unhealthy[addr] = true
+	p.mu.Unlock()
+}
+
+func (p *serverPool) markHealthy(addr string) {
+	p.mu.Lock()
+	if p.unhealthy[addr] {
+		delete(p.unhealthy, addr)
+		p.wakeLocked()
+	}
+	p.mu.Unlock()
+}
+
+// retryPolicy задаёт число попыток на одну секцию и паузу между ними
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration // пауза перед второй попыткой, дальше удваивается
+}
+
+// grepCall выполняет запрос одной секции на конкретном сервере; limits —
+// лимиты этого сервера на одно сообщение
+type grepCall func(ctx context.Context, client pb.GrepServiceClient, limits messageLimits) (*pb.GrepResponse, error)
+
+// runWithRetry выполняет call для секции, при ошибке выжидает паузу и
+// переназначает секцию на другой здоровый сервер. Ошибки, которые не
+// исправить повтором (неверный паттерн, ошибка чтения файла), возвращаются
+// сразу. Отказ занятого сервера (ResourceExhausted с RetryInfo) попыткой
+// не считается: сервер не получает секций названное им время (без него —
+// policy.backoff), а секция уходит на другой сервер или ждёт.
+func (p *serverPool) runWithRetry(
+	ctx context.Context,
+	index int,
+	policy retryPolicy,
+	call grepCall,
+) (*pb.GrepResponse, error) {
+	resp, _, err := runOn(ctx, p, fmt.Sprintf("chunk %d", index), index, newPlacement(false), policy, call)
+	return resp, err
+}
+
+// runOn — общая часть runWithRetry, реплик и служебных запросов: what
+// описывает запрос для лога, preferred задаёт сервер первой попытки,
+// pl — серверы, уже задействованные для этой секции. Вместе с ответом
+// возвращается адрес сервера, который его дал.
+func runOn[T any](
+	ctx context.Context,
+	p *serverPool,
+	what string,
+	preferred int,
+	pl *placement,
+	policy retryPolicy,
+	call func(ctx context.Context, client pb.GrepServiceClient, limits messageLimits) (T, error),
+) (T, string, error) {
+	backoff := policy.backoff
+
+	var zero T
+	var lastErr error
+	for attempt := 0; attempt < max(policy.attempts, 1); {
+		addr, client, release, err := p.acquire(ctx, preferred, pl)
+		if err != nil {
+			return zero, "", errors.Join(lastErr, err)
+		}
+		resp, err := call(ctx, client, p.messageLimits(addr))
+		release()
+		if err == nil {
+			p.markHealthy(addr)
+			return resp, addr, nil
+		}
+		p.dropVoter(pl, addr)
+		lastErr = fmt.Errorf("%s: %w", addr, err)
+		if ctx.Err() != nil {
+			return zero, "", lastErr
+		}
+		if delay, ok := retryDelay(err); ok {
+			if delay <= 0 {
+				delay = policy.backoff
+			}
+			p.backOff(addr, delay)
+			continue
+		}
+		if !retryable(err) {
+			return zero, "", lastErr
+		}
+
+		p.markFailed(addr)
+		attempt++
+		if attempt < policy.attempts {
+			log.Printf("%s failed on %s, retrying (attempt %d/%d): %v", what, addr, attempt, policy.attempts, err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return zero, "", errors.Join(lastErr, ctx.Err())
+			}
+			backoff *= 2
+		}
+	}
+	return zero, "", lastErr
+}
+
+// retryDelay возвращает паузу, которую попросил выждать сервер, отклонив
+// запрос из-за занятости: ResourceExhausted с RetryInfo в деталях
+func retryDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// retryable сообщает, имеет ли смысл повторить запрос на другом сервере.
+// Сервер помечается нездоровым только за ошибки, которые стоит повторять,
+// поэтому локальные ошибки его не задевают.
+func retryable(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || marshalFailed(st) {
+		// Локальная ошибка, например чтения файла
+		return false
+	}
+	switch st.Code() {
+	case codes.InvalidArgument, codes.Unimplemented, codes.PermissionDenied, codes.Unauthenticated,
+		codes.NotFound, codes.FailedPrecondition, codes.OutOfRange:
+		return false
+	case codes.ResourceExhausted:
+		// Без RetryInfo это отказ по размеру сообщения: другой сервер
+		// с теми же лимитами ответит так же
+		return false
+	}
+	return true
+}
+
+// marshalFailed сообщает, что статус создал сам клиент: запрос не удалось
+// сериализовать, например строка с -payload lines не в UTF-8. До сервера
+// такой запрос не доходит, и на любом другом сервере будет то же самое.
+func marshalFailed(st *status.Status) bool {
+	return st.Code() == codes.Internal && strings.HasPrefix(st.Message(), "grpc: error while marshaling")
+}
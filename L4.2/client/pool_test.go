@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// fakeClient помечает, какому серверу ушёл запрос
+type fakeClient struct {
+	pb.GrepServiceClient
+	addr string
+}
+
+func newFakePool(addrs ...string) *serverPool {
+	p := &serverPool{
+		addrs:     addrs,
+		clients:   make(map[string]pb.GrepServiceClient),
+		unhealthy: make(map[string]bool),
+
+		maxInflight: 2,
+		inflight:    make(map[string]int),
+		limits:      make(map[string]int),
+		freed:       make(chan struct{}),
+		busyUntil:   make(map[string]time.Time),
+		messages:    make(map[string]messageLimits),
+	}
+	for _, addr := range addrs {
+		p.clients[addr] = fakeClient{addr: addr}
+	}
+	return p
+}
+
+func TestRunWithRetryReassigns(t *testing.T) {
+	p := newFakePool("a", "b", "c")
+	policy := retryPolicy{attempts: 3, backoff: time.Millisecond}
+
+	var calls []string
+	resp, err := p.runWithRetry(context.Background(), 1, policy, func(_ context.Context, c pb.GrepServiceClient, _ messageLimits) (*pb.GrepResponse, error) {
+		addr := c.(fakeClient).addr
+		calls = append(calls, addr)
+		if addr == "b" {
+			return nil, status.Error(codes.Unavailable, "down")
+		}
+		return &pb.GrepResponse{Count: 7}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 7 {
+		t.Errorf("Count = %d, want 7", resp.Count)
+	}
+	// Секция 1 сначала идёт на свой сервер b, затем на следующий здоровый
+	if len(calls) != 2 || calls[0] != "b" || calls[1] != "c" {
+		t.Errorf("calls = %v, want [b c]", calls)
+	}
+	if !p.unhealthy["b"] {
+		t.Error("failed server is not marked unhealthy")
+	}
+}
+
+func TestRunWithRetryGivesUp(t *testing.T) {
+	p := newFakePool("a", "b")
+	policy := retryPolicy{attempts: 3, backoff: time.Millisecond}
+
+	calls := 0
+	_, err := p.runWithRetry(context.Background(), 0, policy, func(context.Context, pb.GrepServiceClient, messageLimits) (*pb.GrepResponse, error) {
+		calls++
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+	if err == nil {
+		t.Fatal("expected error after all attempts failed")
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRunWithRetryStopsOnPermanentError(t *testing.T) {
+	p := newFakePool("a", "b")
+	policy := retryPolicy{attempts: 3, backoff: time.Millisecond}
+
+	cases := []error{
+		status.Error(codes.InvalidArgument, "bad pattern"),
+		errors.New("failed to read file"),
+		// Так gRPC отвечает, если строка с -payload lines не в UTF-8: запрос
+		// не ушёл с клиента, и сервер в этом не виноват
+		status.Error(codes.Internal, "grpc: error while marshaling: string field contains invalid UTF-8"),
+	}
+	for _, want := range cases {
+		calls := 0
+		_, err := p.runWithRetry(context.Background(), 0, policy, func(context.Context, pb.GrepServiceClient, messageLimits) (*pb.GrepResponse, error) {
+			calls++
+			return nil, want
+		})
+		if !errors.Is(err, want) {
+			t.Errorf("err = %v, want %v", err, want)
+		}
+		if calls != 1 {
+			t.Errorf("%v: calls = %d, want 1", want, calls)
+		}
+		if len(p.unhealthy) > 0 {
+			t.Errorf("%v: servers marked unhealthy: %v", want, p.unhealthy)
+		}
+	}
+}
+
+func TestSchedulerFavoursFastServers(t *testing.T) {
+	p := newFakePool("slow", "fast")
+	p.setCapacity("fast", &pb.InfoResponse{CpuCount: 8, Load1: 0.5})
+	if p.limits["fast"] != 2 {
+		t.Fatalf("fast limit = %d, want 2 (maxInflight)", p.limits["fast"])
+	}
+	p.setCapacity("slow", &pb.InfoResponse{CpuCount: 4, Load1: 7, ActiveRequests: 2})
+	if p.limits["slow"] != 1 {
+		t.Fatalf("slow limit = %d, want 1", p.limits["slow"])
+	}
+
+	var mu sync.Mutex
+	done := make(map[string]int)
+	running := make(map[string]int)
+	peak := make(map[string]int)
+
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.runWithRetry(context.Background(), i, retryPolicy{attempts: 1}, func(_ context.Context, c pb.GrepServiceClient, _ messageLimits) (*pb.GrepResponse, error) {
+				addr := c.(fakeClient).addr
+				mu.Lock()
+				running[addr]++
+				peak[addr] = max(peak[addr], running[addr])
+				mu.Unlock()
+
+				if addr == "slow" {
+					time.Sleep(20 * time.Millisecond)
+				} else {
+					time.Sleep(2 * time.Millisecond)
+				}
+
+				mu.Lock()
+				running[addr]--
+				done[addr]++
+				mu.Unlock()
+				return &pb.GrepResponse{}, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak["slow"] > 1 || peak["fast"] > 2 {
+		t.Errorf("in flight peak = %v, limits = %v", peak, p.limits)
+	}
+	if done["fast"] <= done["slow"]*2 {
+		t.Errorf("chunks done = %v: the fast server should take most of the work", done)
+	}
+}
+
+func TestRunWithRetryHonoursRetryInfo(t *testing.T) {
+	busy, err := status.New(codes.ResourceExhausted, "busy").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(50 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Отказ занятого сервера не тратит единственную попытку
+	policy := retryPolicy{attempts: 1, backoff: time.Millisecond}
+
+	// Пока b занят, секция идёт на a, а b её не получает
+	p := newFakePool("a", "b")
+	var calls []string
+	call := func(_ context.Context, c pb.GrepServiceClient, _ messageLimits) (*pb.GrepResponse, error) {
+		addr := c.(fakeClient).addr
+		calls = append(calls, addr)
+		if addr == "b" {
+			return nil, busy.Err()
+		}
+		return &pb.GrepResponse{}, nil
+	}
+	for _, index := range []int{1, 1} {
+		if _, err := p.runWithRetry(context.Background(), index, policy, call); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(calls, []string{"b", "a", "a"}) {
+		t.Errorf("calls = %v, want [b a a]", calls)
+	}
+	if p.unhealthy["b"] {
+		t.Error("busy server is marked unhealthy")
+	}
+
+	// Единственный сервер повторяется не раньше, чем он попросил
+	p = newFakePool("a")
+	var times []time.Time
+	_, err = p.runWithRetry(context.Background(), 0, policy, func(context.Context, pb.GrepServiceClient, messageLimits) (*pb.GrepResponse, error) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			return nil, busy.Err()
+		}
+		return &pb.GrepResponse{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 2 || times[1].Sub(times[0]) < 50*time.Millisecond {
+		t.Errorf("retried after %v, want at least 50ms", times[len(times)-1].Sub(times[0]))
+	}
+
+	// Без RetryInfo ResourceExhausted не повторяется
+	calls = nil
+	_, err = newFakePool("a", "b").runWithRetry(context.Background(), 0, retryPolicy{attempts: 3}, func(_ context.Context, c pb.GrepServiceClient, _ messageLimits) (*pb.GrepResponse, error) {
+		calls = append(calls, c.(fakeClient).addr)
+		return nil, status.Error(codes.ResourceExhausted, "message too large")
+	})
+	if err == nil || len(calls) != 1 {
+		t.Errorf("oversized message: calls = %v, err = %v", calls, err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+
+	pb "grpc-grep/proto"
+)
+
+// splitRange делит файл размера size на n равных байтовых диапазонов.
+// Границы не выравниваются: в режиме -remote это делает сервер.
+func splitRange(size int64, n int) []section {
+	sections := make([]section, n)
+	for i := range sections {
+		sections[i] = section{
+			start: size * int64(i) / int64(n),
+			end:   size * int64(i+1) / int64(n),
+		}
+	}
+	return sections
+}
+
+// statRemote узнаёт размер файла на серверах, перебирая их при ошибках
+func statRemote(ctx context.Context, pool *serverPool, path string, policy retryPolicy) (int64, error) {
+	size, _, err := runOn(ctx, pool, "stat "+path, 0, newPlacement(false), policy,
+		func(ctx context.Context, client pb.GrepServiceClient, _ messageLimits) (int64, error) {
+			resp, err := client.StatFile(ctx, &pb.StatFileRequest{Path: path})
+			if err != nil {
+				return 0, err
+			}
+			return resp.Size, nil
+		})
+	return size, err
+}
+
+// grepRemoteSection просит сервер прочитать диапазон файла со своего диска.
+// Строки в ответе нумеруются от начала диапазона: номер его первой строки
+// searchFile узнаёт из range_lines предыдущих диапазонов.
+func grepRemoteSection(
+	ctx context.Context,
+	client pb.GrepServiceClient,
+	path string,
+	sec section,
+	query *pb.GrepRequest,
+) (*pb.GrepResponse, error) {
+	stream, err := client.GrepFile(ctx, &pb.GrepFileRequest{
+		Path:          path,
+		Start:         sec.start,
+		End:           sec.end,
+		Query:         query,
+		RelativeLines: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collectResponses(stream.Recv)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// vote — ответ одной реплики секции
+type vote struct {
+	addr   string
+	resp   *pb.GrepResponse
+	digest [sha256.Size]byte
+}
+
+// runReplicated отправляет секцию на replicas разных серверов и принимает
+// ответ, только если его вернуло большинство реплик (replicas/2 + 1).
+// Серверы, ответ которых расходится с большинством, попадают в лог.
+// Упавшая реплика повторяется на другом сервере по той же политике, что и
+// обычная секция, но никогда — на сервере, который уже голосует за эту
+// секцию. Если таких серверов не хватает на большинство, секция не
+// обработана.
+func (p *serverPool) runReplicated(
+	ctx context.Context,
+	index int,
+	replicas int,
+	policy retryPolicy,
+	call grepCall,
+) (*pb.GrepResponse, error) {
+	if replicas <= 1 {
+		return p.runWithRetry(ctx, index, policy, call)
+	}
+
+	pl := newPlacement(true)
+	votes := make([]*vote, replicas)
+	errs := make([]error, replicas)
+
+	var wg sync.WaitGroup
+	for r := 0; r < replicas; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Реплики начинают с разных серверов, чтобы не дублировать друг друга
+			resp, addr, err := runOn(ctx, p, fmt.Sprintf("chunk %d", index), index+r, pl, policy, call)
+			if err != nil {
+				errs[r] = err
+				return
+			}
+			votes[r] = &vote{addr: addr, resp: resp, digest: responseDigest(resp)}
+		}()
+	}
+	wg.Wait()
+
+	// Группируем одинаковые ответы и ищем самую большую группу
+	groups := make(map[[sha256.Size]byte][]*vote)
+	var best [sha256.Size]byte
+	for _, v := range votes {
+		if v == nil {
+			continue
+		}
+		groups[v.digest] = append(groups[v.digest], v)
+		if len(groups[v.digest]) > len(groups[best]) {
+			best = v.digest
+		}
+	}
+
+	majority := replicas/2 + 1
+	winners := groups[best]
+	if len(winners) < majority {
+		var addrs []string
+		for _, v := range votes {
+			if v != nil {
+				addrs = append(addrs, v.addr)
+			}
+		}
+		return nil, errors.Join(
+			fmt.Errorf("no majority: %d of %d replicas agree (servers answered: %s)",
+				len(winners), replicas, strings.Join(addrs, ", ")),
+			errors.Join(errs...),
+		)
+	}
+
+	for digest, group := range groups {
+		if digest == best {
+			continue
+		}
+		for _, v := range group {
+			log.Printf("chunk %d: server %s disagrees with the majority of replicas", index, v.addr)
+		}
+	}
+	return winners[0].resp, nil
+}
+
+// responseDigest вычисляет отпечаток ответа для сравнения реплик. Ответ
+// уже пришёл по сети, поэтому сериализуется без ошибок; детерминированная
+// сериализация даёт одинаковые байты для одинаковых ответов.
+func responseDigest(resp *pb.GrepResponse) [sha256.Size]byte {
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(resp)
+	return sha256.Sum256(data)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// answers возвращает grepCall, где каждый сервер отвечает своим выводом
+func answers(out map[string][]string) grepCall {
+	return func(_ context.Context, c pb.GrepServiceClient, _ messageLimits) (*pb.GrepResponse, error) {
+		lines, ok := out[c.(fakeClient).addr]
+		if !ok {
+			return nil, status.Error(codes.Unavailable, "down")
+		}
+		resp := &pb.GrepResponse{}
+		for i, line := range lines {
+			resp.Matches = append(resp.Matches, &pb.Match{LineNumber: int64(i + 1), Text: []byte(line)})
+		}
+		return resp, nil
+	}
+}
+
+// matchTexts возвращает тексты строк ответа
+func matchTexts(resp *pb.GrepResponse) []string {
+	var texts []string
+	for _, m := range resp.Matches {
+		texts = append(texts, string(m.Text))
+	}
+	return texts
+}
+
+func TestRunReplicatedMajority(t *testing.T) {
+	p := newFakePool("a", "b", "c")
+	policy := retryPolicy{attempts: 1, backoff: time.Millisecond}
+
+	resp, err := p.runReplicated(context.Background(), 0, 3, policy, answers(map[string][]string{
+		"a": {"x"},
+		"b": {"corrupted"},
+		"c": {"x"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := matchTexts(resp); !slices.Equal(got, []string{"x"}) {
+		t.Errorf("matches = %q, want the majority answer", got)
+	}
+}
+
+func TestRunReplicatedNoMajority(t *testing.T) {
+	p := newFakePool("a", "b")
+	policy := retryPolicy{attempts: 1, backoff: time.Millisecond}
+
+	_, err := p.runReplicated(context.Background(), 0, 2, policy, answers(map[string][]string{
+		"a": {"x"},
+		"b": {"y"},
+	}))
+	if err == nil {
+		t.Fatal("expected error when replicas disagree")
+	}
+}
+
+func TestRunReplicatedRetriesFailedReplica(t *testing.T) {
+	p := newFakePool("a", "b", "c")
+	policy := retryPolicy{attempts: 2, backoff: time.Millisecond}
+
+	// Сервер b недоступен: его реплика уходит на свободный сервер c
+	resp, err := p.runReplicated(context.Background(), 0, 2, policy, answers(map[string][]string{
+		"a": {"x"},
+		"c": {"x"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := matchTexts(resp); !slices.Equal(got, []string{"x"}) {
+		t.Errorf("matches = %q, want [x]", got)
+	}
+}
+
+func TestRunReplicatedNeverCountsServerTwice(t *testing.T) {
+	p := newFakePool("a", "b")
+	policy := retryPolicy{attempts: 2, backoff: time.Millisecond}
+
+	// Сервер b недоступен, а a уже голосует: повтор реплики не должен
+	// вернуться на a, иначе один ответ засчитается за два голоса
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	answer := answers(map[string][]string{"a": {"x"}})
+	_, err := p.runReplicated(context.Background(), 0, 2, policy, func(ctx context.Context, c pb.GrepServiceClient, limits messageLimits) (*pb.GrepResponse, error) {
+		mu.Lock()
+		calls[c.(fakeClient).addr]++
+		mu.Unlock()
+		return answer(ctx, c, limits)
+	})
+	if err == nil {
+		t.Fatal("expected error: only one server can vote")
+	}
+	if calls["a"] != 1 {
+		t.Errorf("server a answered %d times, want 1", calls["a"])
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sync/atomic"
+
+	"grpc-grep/internal/lineio"
+	pb "grpc-grep/proto"
+)
+
+// searcher распределяет поиск по серверам: каждый файл делится на секции,
+// а секции всех файлов идут на серверы по кругу
+type searcher struct {
+	pool     *serverPool
+	policy   retryPolicy
+	replicas int
+	remote   bool
+	block    bool // передавать строки блоками байт, а не списком строк
+	query    func() *pb.GrepRequest
+
+	maxCount  int  // -m на файл; 0 — без ограничения
+	after     int  // -A: после -m-й строки нужен ещё её контекст
+	countOnly bool // сервер возвращает только count
+	anyMatch  bool // для -l и -L достаточно знать, есть ли совпадения
+
+	// slots ограничивает число секций, выданных в работу: ждущих сервера и
+	// уже отправленных; next задаёт сервер первой попытки при равной
+	// загрузке серверов
+	slots chan struct{}
+	next  atomic.Int64
+}
+
+// fileResult — итог поиска по одному файлу
+type fileResult struct {
+	resps  []*pb.GrepResponse // ответы секций по порядку; nil — секция не получена
+	ready  int                // сколько первых секций получено подряд
+	failed int                // секции, которые не удалось обработать ни на одном сервере
+	err    error              // файл не удалось открыть, разбить на секции или дочитать
+}
+
+// job — секция в очереди поиска
+type job struct {
+	call grepCall // nil — пустая секция, для которой сервер не нужен
+	// firstLine — номер первой строки секции от начала файла; -1 — его
+	// знает только сервер (-remote). Тогда строки нумеруются от начала
+	// секции, а сборщик сдвигает номера, получив секции перед ней.
+	firstLine int
+}
+
+// queueJob ставит секцию в очередь поиска. Место в s.slots занимается
+// здесь, до отправки, поэтому поток вроде stdin читается не быстрее, чем
+// серверы успевают его обработать.
+func (s *searcher) queueJob(ctx context.Context, jobs chan<- job, j job) error {
+	if j.call != nil {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	select {
+	case jobs <- j:
+		return nil
+	case <-ctx.Done():
+		if j.call != nil {
+			<-s.slots
+		}
+		return ctx.Err()
+	}
+}
+
+// produce делит вход path на секции и ставит их в очередь по порядку
+func (s *searcher) produce(ctx context.Context, path string, jobs chan<- job) error {
+	if s.remote {
+		// Файл лежит на серверах: клиент только делит его размер на диапазоны
+		size, err := statRemote(ctx, s.pool, path, s.policy)
+		if err != nil {
+			return err
+		}
+		for _, sec := range splitRange(size, sectionCount(size)) {
+			if err := s.queueJob(ctx, jobs, s.sectionJob(sec, -1, func(ctx context.Context, client pb.GrepServiceClient, _ messageLimits) (*pb.GrepResponse, error) {
+				return grepRemoteSection(ctx, client, path, sec, s.query())
+			})); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Stdin, каналы и сжатые файлы нельзя делить по байтам: они читаются
+	// одним потоком, который режется на секции по ходу чтения
+	in, err := openStream(path)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		defer in.Close()
+		return s.streamJobs(ctx, in, jobs)
+	}
+
+	sections, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	// Файл делится по байтам, строки читаются и отправляются потоком
+	for _, sec := range sections {
+		if err := s.queueJob(ctx, jobs, s.sectionJob(sec, sec.firstLine, func(ctx context.Context, client pb.GrepServiceClient, limits messageLimits) (*pb.GrepResponse, error) {
+			return grepSection(ctx, client, path, sec, s.query(), s.block, limits)
+		})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamChunk — секция потока, прочитанная в память
+type streamChunk struct {
+	data      []byte   // строки секции вместе с переводами строк
+	lines     int      // число строк в data
+	firstLine int      // номер первой строки от начала потока
+	start     int64    // смещение data от начала потока в байтах
+	lead      []string // halo-строки перед секцией для -A
+}
+
+// streamJobs читает поток r, режет его на секции по chunkBytes и ставит
+// каждую в очередь, как только прочитаны halo-строки после неё. Секция
+// хранится в памяти, пока её не обработают (она может понадобиться для
+// повтора), поэтому в памяти не больше cap(s.slots) секций.
+func (s *searcher) streamJobs(ctx context.Context, r io.Reader, jobs chan<- job) error {
+	q := s.query()
+	after, before := int(q.After), int(q.Before)
+	if q.CountOnly {
+		after, before = 0, 0
+	}
+
+	// queue — прочитанные секции, для которых ещё не набран хвост -B
+	var queue []*streamChunk
+	var recent []string // последние after строк потока
+	cur := &streamChunk{}
+
+	flush := func(eof bool) error {
+		for len(queue) > 0 {
+			var trail []string
+			for _, c := range queue[1:] {
+				trail = append(trail, firstLines(c.data, before-len(trail))...)
+			}
+			if len(trail) < before && !eof {
+				return nil
+			}
+			c := queue[0]
+			queue = queue[1:]
+			err := s.queueJob(ctx, jobs, job{firstLine: c.firstLine, call: func(ctx context.Context, client pb.GrepServiceClient, limits messageLimits) (*pb.GrepResponse, error) {
+				query := s.query()
+				query.LineOffset = int32(c.firstLine - len(c.lead))
+				query.ByteOffset = c.start - lineio.Size(c.lead)
+				return grepStream(ctx, client, bytes.NewReader(c.data), query, c.lead, trail, s.block, limits)
+			}})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := lineio.NewScanner(r)
+	scanner.Split(scanRawLines)
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		cur.data = append(cur.data, raw...)
+		cur.lines++
+		if after > 0 {
+			recent = append(recent, lineText(raw))
+			if len(recent) > after {
+				recent = recent[1:]
+			}
+		}
+
+		if len(cur.data) >= chunkBytes {
+			queue = append(queue, cur)
+			cur = &streamChunk{
+				firstLine: cur.firstLine + cur.lines,
+				start:     cur.start + int64(len(cur.data)),
+				lead:      slices.Clone(recent),
+			}
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+	}
+	// Прочитанное до ошибки всё равно ищется: битый хвост архива не должен
+	// прятать совпадения в его начале
+	if cur.lines > 0 {
+		queue = append(queue, cur)
+	}
+	if err := flush(true); err != nil {
+		return err
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	return nil
+}
+
+// scanRawLines — bufio.SplitFunc, который, в отличие от bufio.ScanLines,
+// оставляет перевод строки в конце строки
+func scanRawLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// lineText возвращает текст строки raw без перевода строки так же, как
+// его возвращает lineio.NewScanner
+func lineText(raw []byte) string {
+	return string(bytes.TrimSuffix(raw, []byte{'\n'}))
+}
+
+// firstLines возвращает до n первых строк data
+func firstLines(data []byte, n int) []string {
+	var lines []string
+	for len(lines) < n && len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			i = len(data) - 1
+		}
+		lines = append(lines, lineText(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// sectionJob возвращает задание с grep для непустой секции и без него для
+// пустой
+func (s *searcher) sectionJob(sec section, firstLine int, grep grepCall) job {
+	if sec.start == sec.end {
+		return job{firstLine: firstLine}
+	}
+	return job{call: grep, firstLine: firstLine}
+}
+
+// splitPath делит обычный файл path на секции по chunkBytes
+func splitPath(path string) ([]section, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return splitFile(f, sectionCount(info.Size()))
+}
+
+// searchFile ищет по всем секциям файла. С -m секции отменяются, когда
+// набран лимит и получены все секции с его контекстом -A; с -l и -L — все
+// секции после первого совпадения.
+func (s *searcher) searchFile(ctx context.Context, path string) fileResult {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	jobs := make(chan job)
+	produceErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		produceErr <- s.produce(ctx, path, jobs)
+	}()
+
+	results := make(chan result)
+	res := fileResult{}
+	var starts []int       // номера первых строк секций, -1 — ещё неизвестен
+	selected := 0          // сколько строк выбрали первые res.ready секций
+	running := 0           // секции, ответ по которым ещё не получен
+	stopped := false       // ответ уже известен, остальные секции не нужны
+	limitLine := int64(-1) // номер -m-й выбранной строки, -1 — лимит не набран
+
+	// covered сообщает, что получены все секции со строками до line
+	// включительно: следующая неполученная секция начинается после неё
+	covered := func(line int64) bool {
+		i := res.ready
+		switch {
+		case i == len(starts) && jobs == nil:
+			return true
+		case i < len(starts) && starts[i] >= 0:
+			return int64(starts[i]) >= line
+		case s.remote:
+			return int64(lineAfter(starts, res.resps, i)) >= line
+		}
+		// Следующая секция ещё не поставлена в очередь
+		return false
+	}
+	checkLimit := func() {
+		if !stopped && limitLine >= 0 && covered(limitLine+int64(s.after)) {
+			stopped = true
+			stop()
+		}
+	}
+
+	// Сбор результатов: каждая секция либо обработана, либо исчерпала
+	// попытки. Число секций потока заранее неизвестно, поэтому новые
+	// секции и ответы принимаются вперемешку.
+	for jobs != nil || running > 0 {
+		select {
+		case j, ok := <-jobs:
+			if !ok {
+				jobs = nil
+				checkLimit()
+				continue
+			}
+			rank := len(res.resps)
+			res.resps = append(res.resps, nil)
+			starts = append(starts, j.firstLine)
+			running++
+			checkLimit()
+
+			grepChunk := j.call
+			if grepChunk == nil {
+				go func() { results <- result{rank: rank, resp: &pb.GrepResponse{}} }()
+				continue
+			}
+			index := int(s.next.Add(1) - 1)
+			go func() {
+				resp, err := s.pool.runReplicated(ctx, index, s.replicas, s.policy, grepChunk)
+				// Место освобождается сразу, не дожидаясь, пока сборщик
+				// примет ответ
+				<-s.slots
+				results <- result{rank: rank, resp: resp, err: err}
+			}()
+
+		case r := <-results:
+			running--
+			if stopped {
+				continue
+			}
+			if r.err != nil {
+				log.Printf("%s: chunk %d failed: %v", displayName(path), r.rank, r.err)
+				res.failed++
+				continue
+			}
+			res.resps[r.rank] = r.resp
+
+			for res.ready < len(res.resps) && res.resps[res.ready] != nil {
+				i := res.ready
+				if starts[i] < 0 {
+					starts[i] = lineAfter(starts, res.resps, i)
+					shiftLines(res.resps[i], starts[i])
+				}
+				n := selectedLines(res.resps[i], s.countOnly)
+				if s.maxCount > 0 && limitLine < 0 && selected+n >= s.maxCount {
+					limitLine = nthSelected(res.resps[i], s.maxCount-selected)
+				}
+				selected += n
+				res.ready++
+			}
+			if s.anyMatch && selectedLines(r.resp, s.countOnly) > 0 {
+				stopped = true
+				stop()
+			}
+			checkLimit()
+		}
+	}
+
+	if err := <-produceErr; err != nil && !stopped {
+		res.err = err
+	}
+	// Секции -remote за неполученной нумеровать не от чего: их строки не
+	// печатаются, но итоги -c, -l и -L в них остаются
+	for i := res.ready; i < len(res.resps); i++ {
+		if res.resps[i] != nil && starts[i] < 0 {
+			res.resps[i].Matches = nil
+		}
+	}
+	return res
+}
+
+// lineAfter возвращает номер первой строки секции i по секциям перед ней:
+// номер первой строки предыдущей секции плюс число строк в ней
+func lineAfter(starts []int, resps []*pb.GrepResponse, i int) int {
+	if i == 0 {
+		return 0
+	}
+	return starts[i-1] + int(resps[i-1].RangeLines)
+}
+
+// nthSelected возвращает номер n-й выбранной строки ответа. Для -c номера
+// строк не нужны, и возвращается 0.
+func nthSelected(resp *pb.GrepResponse, n int) int64 {
+	for _, m := range resp.Matches {
+		if m.Context {
+			continue
+		}
+		if n--; n == 0 {
+			return m.LineNumber
+		}
+	}
+	return 0
+}
+
+// shiftLines переводит номера строк ответа из отсчёта от начала секции в
+// отсчёт от начала файла
+func shiftLines(resp *pb.GrepResponse, firstLine int) {
+	for _, m := range resp.Matches {
+		m.LineNumber += int64(firstLine)
+	}
+}
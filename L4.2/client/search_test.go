@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"grpc-grep/internal/lineio"
+	"grpc-grep/internal/render"
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc"
+)
+
+// fakeGrepServer ищет в памяти так же, как настоящий сервер: halo-строки
+// дают контекст, но не печатаются, а -m ограничивает поток
+type fakeGrepServer struct {
+	pb.GrepServiceClient
+	// delay задерживает ответ на поток; по нему можно придержать секции
+	delay func(query *pb.GrepRequest) time.Duration
+}
+
+func (f fakeGrepServer) GrepStream(ctx context.Context, _ ...grpc.CallOption) (grpc.BidiStreamingClient[pb.GrepRequest, pb.GrepResponse], error) {
+	return &fakeGrepStream{ctx: ctx, server: f, closed: make(chan struct{})}, nil
+}
+
+type fakeGrepStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	server fakeGrepServer
+
+	mu     sync.Mutex
+	reqs   []*pb.GrepRequest
+	closed chan struct{}
+	resp   *pb.GrepResponse
+}
+
+func (s *fakeGrepStream) Send(req *pb.GrepRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reqs = append(s.reqs, req)
+	return nil
+}
+
+func (s *fakeGrepStream) CloseSend() error {
+	close(s.closed)
+	return nil
+}
+
+func (s *fakeGrepStream) Recv() (*pb.GrepResponse, error) {
+	select {
+	case <-s.closed:
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+	if s.resp != nil {
+		return nil, io.EOF
+	}
+	s.resp = s.grep()
+	if s.server.delay != nil {
+		select {
+		case <-time.After(s.server.delay(s.reqs[0])):
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+	}
+	return s.resp, nil
+}
+
+func (s *fakeGrepStream) grep() *pb.GrepResponse {
+	q := s.reqs[0]
+	re := regexp.MustCompile(strings.Join(q.Patterns, "|"))
+
+	type line struct {
+		text []byte
+		halo bool
+	}
+	var lines []line
+	for _, req := range s.reqs {
+		texts := req.Lines
+		if len(req.Block) > 0 {
+			texts = lineio.SplitBlock(req.Block)
+		}
+		for _, text := range texts {
+			lines = append(lines, line{[]byte(lineio.TrimCR(text)), req.Halo})
+		}
+	}
+
+	// Как и GNU grep, с -o строки контекста не печатаются
+	after, before := int(q.After), int(q.Before)
+	if q.OnlyMatching {
+		after, before = 0, 0
+	}
+
+	resp := &pb.GrepResponse{}
+	emit := func(i int, context bool) {
+		if lines[i].halo || q.CountOnly {
+			return
+		}
+		m := &pb.Match{
+			LineNumber: int64(q.LineOffset) + int64(i) + 1,
+			Text:       lines[i].text,
+			Context:    context,
+		}
+		if q.OnlyMatching && !context {
+			for _, loc := range re.FindAllIndex(lines[i].text, -1) {
+				m.Submatches = append(m.Submatches, &pb.Submatch{Start: int32(loc[0]), End: int32(loc[1])})
+			}
+		}
+		resp.Matches = append(resp.Matches, m)
+	}
+	count, afterLeft := 0, 0
+	var pending []int
+	for i, l := range lines {
+		if q.MaxCount > 0 && count >= int(q.MaxCount) {
+			if afterLeft == 0 {
+				break
+			}
+			afterLeft--
+			emit(i, true)
+			continue
+		}
+		switch {
+		case re.Match(l.text) != q.Invert:
+			if !l.halo {
+				count++
+			}
+			for _, j := range pending {
+				emit(j, true)
+			}
+			pending = pending[:0]
+			emit(i, false)
+			afterLeft = after
+		case afterLeft > 0:
+			afterLeft--
+			emit(i, true)
+		case before > 0:
+			if len(pending) == before {
+				pending = pending[1:]
+			}
+			pending = append(pending, i)
+		}
+	}
+	if q.CountOnly {
+		resp.Count = int32(count)
+	}
+	return resp
+}
+
+// newFakeGrepPool возвращает пул из трёх серверов fakeGrepServer
+func newFakeGrepPool(server fakeGrepServer) *serverPool {
+	p := newFakePool("a", "b", "c")
+	for addr := range p.clients {
+		p.clients[addr] = server
+	}
+	return p
+}
+
+// grepFlags — флаги поиска, общие для клиента и GNU grep в тестах
+type grepFlags struct {
+	after, before int
+	maxCount      int
+	onlyMatching  bool
+}
+
+// args возвращает те же флаги для GNU grep
+func (f grepFlags) args() []string {
+	// Как и у клиента, с -A 0 или -B 0 GNU grep уже разделяет группы,
+	// поэтому нулевой контекст не передаётся вовсе
+	args := []string{"-n"}
+	if f.after > 0 {
+		args = append(args, "-A", fmt.Sprint(f.after))
+	}
+	if f.before > 0 {
+		args = append(args, "-B", fmt.Sprint(f.before))
+	}
+	if f.maxCount > 0 {
+		args = append(args, "-m", fmt.Sprint(f.maxCount))
+	}
+	if f.onlyMatching {
+		args = append(args, "-o")
+	}
+	return args
+}
+
+// searchOutput ищет pattern в paths на пуле p и печатает результат так же,
+// как main: с -n, разделителями групп и именами файлов, если их несколько
+func searchOutput(t *testing.T, p *serverPool, pattern string, f grepFlags, paths ...string) string {
+	t.Helper()
+
+	s := &searcher{
+		pool:     p,
+		policy:   retryPolicy{attempts: 1},
+		replicas: 1,
+		block:    true,
+		query: func() *pb.GrepRequest {
+			return &pb.GrepRequest{
+				Patterns:     []string{pattern},
+				After:        int32(f.after),
+				Before:       int32(f.before),
+				LineNum:      true,
+				MaxCount:     int32(f.maxCount),
+				OnlyMatching: f.onlyMatching,
+			}
+		},
+		maxCount: f.maxCount,
+		after:    f.after,
+		slots:    make(chan struct{}, 8),
+	}
+
+	var buf bytes.Buffer
+	out := newPrinter(&buf, false, render.Options{LineNum: true, OnlyMatching: f.onlyMatching})
+	out.withNames = len(paths) > 1
+	if f.after > 0 || f.before > 0 {
+		out.groups, out.separator = true, "--"
+		out.before, out.after = f.before, f.after
+	}
+	for _, path := range paths {
+		res := s.searchFile(context.Background(), path)
+		if res.err != nil || res.failed > 0 {
+			t.Fatalf("search %s: %d chunks failed, %v", path, res.failed, res.err)
+		}
+		if err := printFile(out, path, res, fileOutput{maxCount: f.maxCount, after: f.after}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// gnuGrep возвращает вывод GNU grep или пропускает тест, если его нет
+func gnuGrep(t *testing.T, args ...string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep is not installed")
+	}
+	out, err := exec.Command("grep", args...).Output()
+	if err != nil && len(out) > 0 {
+		t.Fatalf("grep %v: %v", args, err)
+	}
+	return string(out)
+}
+
+// fillerLines возвращает n строк одной длины
+func fillerLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %07d of filler text", i)
+	}
+	return lines
+}
+
+func TestMatchLimitWaitsForContext(t *testing.T) {
+	lines := fillerLines(3 * chunkBytes / 26)
+	path := filepath.Join(t.TempDir(), "log.txt")
+	write := func() {
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write()
+	sections, err := splitPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) < 3 {
+		t.Fatalf("got %d sections, want at least 3", len(sections))
+	}
+
+	// -m-я строка — последняя в первой секции, её контекст -A во второй.
+	// Вторая секция отвечает позже первой, чтобы её не успели отменить.
+	last := sections[1].firstLine - 1
+	lines[last] = strings.Replace(lines[last], "filler", "ERROR!", 1)
+	lines[last+2] = strings.Replace(lines[last+2], "filler", "ERROR!", 1)
+	write()
+	p := newFakeGrepPool(fakeGrepServer{delay: func(q *pb.GrepRequest) time.Duration {
+		if q.LineOffset > 0 {
+			return 20 * time.Millisecond
+		}
+		return 0
+	}})
+
+	for _, f := range []grepFlags{{after: 3, maxCount: 1}, {after: 1, maxCount: 1}, {after: 3, maxCount: 2}, {maxCount: 1}} {
+		got := searchOutput(t, p, "ERROR", f, path)
+		want := gnuGrep(t, append(f.args(), "ERROR", path)...)
+		if got != want {
+			t.Errorf("%v: got\n%s\nwant\n%s", f.args(), got, want)
+		}
+	}
+}
+
+func TestStreamContextMatchesGNU(t *testing.T) {
+	// Около шести секций потока. Первая длинная строка закрывает секцию,
+	// две следующие длиннее chunkBytes и занимают по целой секции. Поэтому
+	// хвост -B перед совпадением сразу за ними собирается из нескольких
+	// следующих секций, а halo -A после совпадения перед ними — из
+	// нескольких предыдущих.
+	var lines []string
+	for i, line := range fillerLines(50000) {
+		if i%997 == 0 || i == 49998 {
+			line = strings.Replace(line, "filler", "ERROR!", 1)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines,
+		strings.Repeat("a", chunkBytes+100),
+		strings.Repeat("b", chunkBytes+100),
+		strings.Repeat("c", chunkBytes+100))
+	for i, line := range fillerLines(60000) {
+		if i%1009 == 0 {
+			line = strings.Replace(line, "filler", "ERROR!", 1)
+		}
+		lines = append(lines, line)
+	}
+	path := filepath.Join(t.TempDir(), "stdin.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := os.Stdin
+	t.Cleanup(func() { os.Stdin = stdin })
+	p := newFakeGrepPool(fakeGrepServer{})
+	for _, f := range []grepFlags{{after: 1}, {before: 3}, {after: 2, before: 2}, {after: 5, before: 5}} {
+		in, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stdin = in
+		got := searchOutput(t, p, "ERROR", f, stdinArg)
+		in.Close()
+
+		want := gnuGrep(t, append(f.args(), "ERROR", path)...)
+		if got != want {
+			t.Errorf("%v: %s", f.args(), firstDiff(got, want))
+		}
+	}
+}
+
+// firstDiff описывает первую различающуюся строку got и want: целиком
+// вывод с длинными строками не напечатать
+func firstDiff(got, want string) string {
+	g, w := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := range min(len(g), len(w)) {
+		if g[i] != w[i] {
+			return fmt.Sprintf("line %d: got %.80q, want %.80q", i+1, g[i], w[i])
+		}
+	}
+	return fmt.Sprintf("got %d lines, want %d", len(g), len(w))
+}
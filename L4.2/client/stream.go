@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"grpc-grep/internal/lineio"
+	pb "grpc-grep/proto"
+)
+
+// batchBytes — наибольший размер одного сообщения потока, с запасом ниже
+// лимита gRPC в 4 МБ
+const batchBytes = 1 << 20
+
+// messageLimits — сколько строк и байт строк сервер принимает в одном
+// сообщении GrepRequest (max_request_lines и max_request_bytes из Info);
+// 0 — без ограничения
+type messageLimits struct {
+	lines int
+	bytes int
+}
+
+// batchSize возвращает наибольший размер батча для сервера: batchBytes,
+// но не больше его лимита
+func (l messageLimits) batchSize() int {
+	if l.bytes > 0 {
+		return min(batchBytes, l.bytes)
+	}
+	return batchBytes
+}
+
+// fits сообщает, что в батч из n строк размером size байт вместе с
+// переводами строк помещается ещё строка длины line
+func (l messageLimits) fits(n, size, line int) bool {
+	return (l.lines <= 0 || n < l.lines) && size+line+1 <= l.batchSize()
+}
+
+// chunkBytes — примерный размер секции. Секции мелкие, чтобы быстрые
+// серверы забирали больше работы, а самый медленный задерживал конец
+// поиска не больше чем на одну секцию.
+const chunkBytes = 1 << 20
+
+// sectionCount возвращает, на сколько секций делить файл размером size
+func sectionCount(size int64) int {
+	return int(size/chunkBytes + 1)
+}
+
+// section — часть файла, которую обрабатывает один сервер
+type section struct {
+	start     int64 // байтовый диапазон [start, end)
+	end       int64
+	firstLine int // номер первой строки секции от начала файла
+}
+
+// splitFile делит файл на n секций примерно равного размера, выравнивая
+// границы по началу строки. Файл читается блоками, целиком в память он не
+// загружается.
+func splitFile(f *os.File, n int) ([]section, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+
+	sections := make([]section, 0, n)
+	cur := section{}
+	target := func() int64 { return size * int64(len(sections)+1) / int64(n) }
+
+	buf := make([]byte, 64<<10)
+	var pos int64
+	lines := 0
+	for len(sections) < n-1 {
+		k, err := f.ReadAt(buf, pos)
+		block := buf[:k]
+		for len(sections) < n-1 {
+			i := bytes.IndexByte(block, '\n')
+			if i < 0 {
+				break
+			}
+			lines++
+			lineStart := pos + int64(i) + 1
+			// Одна длинная строка может перекрыть несколько границ подряд
+			for len(sections) < n-1 && lineStart >= target() {
+				cur.end = lineStart
+				sections = append(sections, cur)
+				cur = section{start: lineStart, firstLine: lines}
+			}
+			block = block[i+1:]
+			pos = lineStart
+		}
+		pos += int64(len(block))
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Оставшиеся секции, включая последнюю, тянутся до конца файла
+	for len(sections) < n {
+		cur.end = size
+		sections = append(sections, cur)
+		cur = section{start: size, firstLine: lines}
+	}
+	return sections, nil
+}
+
+// grepSection отправляет строки секции файла path на сервер батчами через
+// GrepStream и собирает ответы в один GrepResponse. Файл открывается на
+// время запроса, поэтому число открытых файлов не растёт с числом файлов
+// поиска.
+func grepSection(
+	ctx context.Context,
+	client pb.GrepServiceClient,
+	path string,
+	sec section,
+	query *pb.GrepRequest,
+	block bool,
+	limits messageLimits,
+) (*pb.GrepResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Halo-строки вокруг секции: совпадения до её начала дают контекст -A,
+	// совпадения после конца — контекст -B для строк самой секции
+	var lead, trail []string
+	if !query.CountOnly {
+		if lead, err = lineio.LinesBefore(f, sec.start, int(query.After)); err != nil {
+			return nil, err
+		}
+		if trail, err = lineio.LinesAfter(f, sec.end, int(query.Before)); err != nil {
+			return nil, err
+		}
+	}
+	query.LineOffset = int32(sec.firstLine - len(lead))
+	query.ByteOffset = sec.start - lineio.Size(lead)
+
+	return grepStream(ctx, client, io.NewSectionReader(f, sec.start, sec.end-sec.start), query, lead, trail, block, limits)
+}
+
+// grepStream отправляет на сервер halo-строки lead, строки из r и
+// halo-строки trail через GrepStream и собирает ответы в один GrepResponse.
+// С block строки идут блоками байт, иначе — списком строк; размер
+// сообщений не превышает limits сервера.
+func grepStream(
+	ctx context.Context,
+	client pb.GrepServiceClient,
+	r io.Reader,
+	query *pb.GrepRequest,
+	lead, trail []string,
+	block bool,
+	limits messageLimits,
+) (*pb.GrepResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.GrepStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		send := sendLines
+		if block {
+			send = sendBlocks
+		}
+		err := send(stream, r, query, lead, trail, limits)
+		if err != nil {
+			// Прерываем поток, чтобы Recv не ждал ответа сервера
+			cancel()
+		}
+		sendErr <- err
+	}()
+
+	result, err := collectResponses(stream.Recv)
+	if err != nil {
+		cancel()
+		if sErr := <-sendErr; sErr != nil {
+			return nil, sErr
+		}
+		return nil, err
+	}
+
+	if err := <-sendErr; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// collectResponses читает ответы потока до конца и склеивает их в один
+func collectResponses(recv func() (*pb.GrepResponse, error)) (*pb.GrepResponse, error) {
+	result := &pb.GrepResponse{}
+	for {
+		resp, err := recv()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Matches = append(result.Matches, resp.Matches...)
+		result.Count += resp.Count
+		result.RangeLines += resp.RangeLines
+	}
+}
+
+// sendLines отправляет halo-строки lead, затем строки из r батчами и в
+// конце halo-строки trail. Первое сообщение несёт параметры поиска из
+// query, даже если строк нет совсем. Ни одно сообщение не превышает
+// limits сервера. Если сервер оборвал поток, Send возвращает io.EOF, а
+// настоящая ошибка придёт в Recv.
+func sendLines(stream pb.GrepService_GrepStreamClient, r io.Reader, query *pb.GrepRequest, lead, trail []string, limits messageLimits) error {
+	req := query
+	for _, batch := range splitBatches(lead, limits) {
+		req.Lines = batch
+		req.Halo = true
+		if err := stream.Send(req); err != nil {
+			return ignoreEOF(err)
+		}
+		req = &pb.GrepRequest{}
+	}
+
+	scanner := lineio.NewScanner(r)
+
+	size := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(req.Lines) > 0 && !limits.fits(len(req.Lines), size, len(line)) {
+			if err := stream.Send(req); err != nil {
+				return ignoreEOF(err)
+			}
+			req = &pb.GrepRequest{}
+			size = 0
+		}
+		req.Lines = append(req.Lines, line)
+		size += len(line) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if req == query || len(req.Lines) > 0 {
+		if err := stream.Send(req); err != nil {
+			return ignoreEOF(err)
+		}
+	}
+	for _, batch := range splitBatches(trail, limits) {
+		if err := stream.Send(&pb.GrepRequest{Lines: batch, Halo: true}); err != nil {
+			return ignoreEOF(err)
+		}
+	}
+	return stream.CloseSend()
+}
+
+// sendBlocks делает то же, что sendLines, но передаёт строки в поле block:
+// байты файла идут как есть, без разбора на строки и проверки UTF-8.
+// Блоки режутся по последнему переводу строки, чтобы строка не попала в
+// два сообщения.
+func sendBlocks(stream pb.GrepService_GrepStreamClient, r io.Reader, query *pb.GrepRequest, lead, trail []string, limits messageLimits) error {
+	req := query
+	for _, batch := range splitBatches(lead, limits) {
+		req.Block = lineio.JoinBlock(batch)
+		req.Halo = true
+		if err := stream.Send(req); err != nil {
+			return ignoreEOF(err)
+		}
+		req = &pb.GrepRequest{}
+	}
+
+	buf := make([]byte, limits.batchSize())
+	filled := 0
+	eof := false
+	for {
+		if !eof {
+			n, err := io.ReadFull(r, buf[filled:])
+			filled += n
+			eof = errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+			if err != nil && !eof {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+		}
+
+		cut := filled
+		if !eof {
+			cut = bytes.LastIndexByte(buf[:filled], '\n') + 1
+			if cut == 0 {
+				// Строка длиннее буфера: буфер растёт до лимита длины строки
+				if len(buf) >= lineio.MaxLineSize+batchBytes {
+					return fmt.Errorf("failed to read file: line longer than %d bytes", lineio.MaxLineSize)
+				}
+				buf = append(buf, make([]byte, len(buf))...)
+				continue
+			}
+		}
+		cut = limits.batchEnd(buf[:cut])
+		// Первое сообщение уходит, даже если строк нет совсем
+		if cut == 0 && req != query {
+			break
+		}
+
+		// Сообщение нельзя менять после Send, поэтому блок отдаётся вместе
+		// с буфером, а недочитанный хвост переезжает в новый. После длинной
+		// строки буфер снова сжимается до размера батча.
+		next := make([]byte, max(limits.batchSize(), filled-cut))
+		filled = copy(next, buf[cut:filled])
+		req.Block = buf[:cut]
+		if err := stream.Send(req); err != nil {
+			return ignoreEOF(err)
+		}
+		req = &pb.GrepRequest{}
+		buf = next
+	}
+
+	for _, batch := range splitBatches(trail, limits) {
+		if err := stream.Send(&pb.GrepRequest{Block: lineio.JoinBlock(batch), Halo: true}); err != nil {
+			return ignoreEOF(err)
+		}
+	}
+	return stream.CloseSend()
+}
+
+// splitBatches делит строки на батчи, каждый из которых сервер с limits
+// примет одним сообщением
+func splitBatches(lines []string, limits messageLimits) [][]string {
+	var batches [][]string
+	start, size := 0, 0
+	for i, line := range lines {
+		if i > start && !limits.fits(i-start, size, len(line)) {
+			batches = append(batches, lines[start:i])
+			start, size = i, 0
+		}
+		size += len(line) + 1
+	}
+	if start < len(lines) {
+		batches = append(batches, lines[start:])
+	}
+	return batches
+}
+
+// batchEnd возвращает длину начала block из целых строк, которое сервер
+// с limits примет одним сообщением, но не меньше одной строки: строку
+// длиннее лимита всё равно нужно отправить, чтобы сервер её отклонил
+func (l messageLimits) batchEnd(block []byte) int {
+	end, n := 0, 0
+	for end < len(block) {
+		next := len(block)
+		if i := bytes.IndexByte(block[end:], '\n'); i >= 0 {
+			next = end + i + 1
+		}
+		if n > 0 && (l.lines > 0 && n >= l.lines || next > l.batchSize()) {
+			break
+		}
+		end = next
+		n++
+	}
+	return end
+}
+
+func ignoreEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
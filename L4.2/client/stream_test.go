@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"grpc-grep/internal/lineio"
+	pb "grpc-grep/proto"
+)
+
+func writeTemp(t *testing.T, content string) *os.File {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestSplitFileCoversAllLines(t *testing.T) {
+	content := "one\ntwo\n" + strings.Repeat("x", 100) + "\nthree\nfour\nfive"
+	f := writeTemp(t, content)
+
+	for n := 1; n <= 8; n++ {
+		sections, err := splitFile(f, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sections) != n {
+			t.Fatalf("n=%d: got %d sections", n, len(sections))
+		}
+
+		// Секции идут подряд, начинаются с начала строки и вместе дают весь файл
+		var joined strings.Builder
+		pos := int64(0)
+		for _, sec := range sections {
+			if sec.start != pos {
+				t.Fatalf("n=%d: section starts at %d, want %d", n, sec.start, pos)
+			}
+			if sec.start > 0 && sec.start < int64(len(content)) && content[sec.start-1] != '\n' {
+				t.Fatalf("n=%d: section starts mid-line at %d", n, sec.start)
+			}
+			if want := strings.Count(content[:sec.start], "\n"); sec.firstLine != want {
+				t.Fatalf("n=%d: firstLine = %d, want %d", n, sec.firstLine, want)
+			}
+			joined.WriteString(content[sec.start:sec.end])
+			pos = sec.end
+		}
+		if joined.String() != content {
+			t.Fatalf("n=%d: sections do not cover the file", n)
+		}
+	}
+}
+
+func TestSendRespectsServerLimits(t *testing.T) {
+	lines := fillerLines(300)
+	lines[17] = strings.Repeat("x", 150) // длиннее иных батчей целиком
+	lead, trail := fillerLines(10), fillerLines(12)
+	content := strings.Join(lines, "\n") + "\n"
+
+	for _, limits := range []messageLimits{{}, {lines: 7}, {bytes: 200}, {lines: 3, bytes: 64}} {
+		for _, block := range []bool{false, true} {
+			send := sendLines
+			if block {
+				send = sendBlocks
+			}
+			stream := &fakeGrepStream{closed: make(chan struct{})}
+			query := &pb.GrepRequest{Patterns: []string{"x"}}
+			if err := send(stream, strings.NewReader(content), query, lead, trail, limits); err != nil {
+				t.Fatal(err)
+			}
+			if stream.reqs[0] != query {
+				t.Errorf("%+v, block %v: first message has no query", limits, block)
+			}
+
+			var halo, body []string
+			for _, req := range stream.reqs {
+				got := req.Lines
+				size := int(lineio.Size(req.Lines))
+				if len(req.Block) > 0 {
+					got, size = lineio.SplitBlock(req.Block), len(req.Block)
+				}
+				// Строка длиннее лимита уходит одна: сервер её отклонит
+				if len(got) > 1 && (limits.lines > 0 && len(got) > limits.lines || limits.bytes > 0 && size > limits.bytes) {
+					t.Errorf("%+v, block %v: message of %d lines, %d bytes", limits, block, len(got), size)
+				}
+				if req.Halo {
+					halo = append(halo, got...)
+				} else {
+					body = append(body, got...)
+				}
+			}
+			if !slices.Equal(body, lines) || !slices.Equal(halo, append(slices.Clone(lead), trail...)) {
+				t.Errorf("%+v, block %v: lines were not sent exactly once in order", limits, block)
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package lineio содержит общие для клиента и сервера функции чтения строк
+// из произвольного места файла: выравнивание по началу строки, подсчёт
+// строк и чтение соседних строк для контекста -A/-B.
+package lineio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MaxLineSize — максимальная длина одной строки входного файла
+const MaxLineSize = 3 << 20
+
+const blockSize = 64 << 10
+
+// NewScanner создаёт сканер строк с буфером на MaxLineSize. В отличие от
+// bufio.ScanLines, он оставляет '\r' перед переводом строки: так длина
+// строки вместе с переводом строки равна её размеру в файле, и смещения
+// строк CRLF-файла не сдвигаются. '\r' отбрасывает поиск, см. TrimCR.
+func NewScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, blockSize), MaxLineSize)
+	scanner.Split(scanLines)
+	return scanner
+}
+
+// scanLines — bufio.SplitFunc, который отбрасывает только перевод строки
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// TrimCR отбрасывает '\r' в конце строки CRLF-файла: он не входит ни в
+// текст, который ищется, ни в текст, который печатается
+func TrimCR(line string) string {
+	return strings.TrimSuffix(line, "\r")
+}
+
+// NextLineStart возвращает позицию начала первой строки, которая
+// начинается не раньше pos. Если pos уже указывает на начало строки,
+// она возвращается без изменений; если до конца файла перевода строки
+// нет, возвращается size.
+func NextLineStart(r io.ReaderAt, pos, size int64) (int64, error) {
+	if pos <= 0 {
+		return 0, nil
+	}
+	if pos >= size {
+		return size, nil
+	}
+
+	buf := make([]byte, blockSize)
+	// Начинаем с байта перед pos: если это перевод строки, pos уже в начале
+	for off := pos - 1; off < size; off += int64(len(buf)) {
+		n, err := r.ReadAt(buf, off)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return off + int64(i) + 1, nil
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// CountLines считает переводы строк в диапазоне [0, end), то есть номер
+// (от нуля) строки, начинающейся в end
+func CountLines(r io.ReaderAt, end int64) (int, error) {
+	buf := make([]byte, blockSize)
+	count := 0
+	for off := int64(0); off < end; off += int64(len(buf)) {
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), end-off)], off)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// LinesBefore возвращает до n строк, непосредственно предшествующих
+// позиции pos. pos должна указывать на начало строки.
+func LinesBefore(r io.ReaderAt, pos int64, n int) ([]string, error) {
+	if n <= 0 || pos == 0 {
+		return nil, nil
+	}
+
+	// Идём назад блоками, пока не встретим n+1 перевод строки: первый из
+	// них завершает строку перед pos, последний — строку перед искомыми
+	buf := make([]byte, blockSize)
+	start := int64(0)
+	newlines := 0
+	end := pos
+scan:
+	for end > 0 {
+		blockStart := max(end-int64(len(buf)), 0)
+		block := buf[:end-blockStart]
+		if _, err := r.ReadAt(block, blockStart); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		for i := len(block) - 1; i >= 0; i-- {
+			if block[i] != '\n' {
+				continue
+			}
+			newlines++
+			if newlines == n+1 {
+				start = blockStart + int64(i) + 1
+				break scan
+			}
+		}
+		end = blockStart
+	}
+
+	return readLines(io.NewSectionReader(r, start, pos-start), n)
+}
+
+// Size возвращает, сколько байт lines занимают в файле вместе с
+// переводами строк: на столько строки из LinesBefore отстоят от pos
+func Size(lines []string) int64 {
+	var size int64
+	for _, line := range lines {
+		size += int64(len(line)) + 1
+	}
+	return size
+}
+
+// LinesAfter возвращает до n строк, начиная с позиции pos
+func LinesAfter(r io.ReaderAt, pos int64, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	return readLines(io.NewSectionReader(r, pos, 1<<62), n)
+}
+
+// readLines читает из r не больше n строк
+func readLines(r io.Reader, n int) ([]string, error) {
+	scanner := NewScanner(r)
+
+	var lines []string
+	for len(lines) < n && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return lines, nil
+}
+
+// SplitBlock делит блок GrepRequest.block на строки. Как и NewScanner, он
+// отбрасывает перевод строки, но оставляет '\r' перед ним. Блок копируется
+// в строку один раз, строки ссылаются на эту копию.
+func SplitBlock(block []byte) []string {
+	s := string(block)
+	lines := make([]string, 0, bytes.Count(block, []byte{'\n'})+1)
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			i = len(s)
+		}
+		lines = append(lines, s[:i])
+		s = s[min(i+1, len(s)):]
+	}
+	return lines
+}
+
+// JoinBlock собирает строки в блок для GrepRequest.block
+func JoinBlock(lines []string) []byte {
+	block := make([]byte, 0, Size(lines))
+	for _, line := range lines {
+		block = append(block, line...)
+		block = append(block, '\n')
+	}
+	return block
+}
@@ -0,0 +1,112 @@
+package lineio
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLinesBeforeAfter(t *testing.T) {
+	content := "l1\nl2\nl3\nl4\nl5\n"
+	r := strings.NewReader(content)
+	l4 := int64(strings.Index(content, "l4"))
+
+	cases := []struct {
+		name string
+		got  func() ([]string, error)
+		want []string
+	}{
+		{"before 2", func() ([]string, error) { return LinesBefore(r, l4, 2) }, []string{"l2", "l3"}},
+		{"before more than available", func() ([]string, error) { return LinesBefore(r, l4, 10) }, []string{"l1", "l2", "l3"}},
+		{"before start of file", func() ([]string, error) { return LinesBefore(r, 0, 3) }, nil},
+		{"after 1", func() ([]string, error) { return LinesAfter(r, l4, 1) }, []string{"l4"}},
+		{"after past end", func() ([]string, error) { return LinesAfter(r, l4, 5) }, []string{"l4", "l5"}},
+	}
+
+	for _, tc := range cases {
+		got, err := tc.got()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSizeCountsCR(t *testing.T) {
+	content := "l1\r\nl2\nl3\r\nl4\n"
+	r := strings.NewReader(content)
+	l4 := int64(strings.Index(content, "l4"))
+
+	lines, err := LinesBefore(r, l4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Строки из LinesBefore отстоят от pos ровно на Size байт
+	if got := Size(lines); got != l4 {
+		t.Errorf("Size(%q) = %d, want %d", lines, got, l4)
+	}
+	if got := string(JoinBlock(lines)); got != content[:l4] {
+		t.Errorf("JoinBlock(%q) = %q, want %q", lines, got, content[:l4])
+	}
+}
+
+func TestNextLineStart(t *testing.T) {
+	content := "abc\ndef\n\nghi"
+	r := strings.NewReader(content)
+	size := int64(len(content))
+
+	cases := []struct {
+		pos, want int64
+	}{
+		{0, 0},
+		{1, 4},   // середина первой строки -> начало второй
+		{4, 4},   // уже начало строки
+		{5, 8},   // середина второй строки -> пустая строка
+		{8, 8},   // начало пустой строки
+		{9, 9},   // начало последней строки
+		{10, 12}, // последняя строка без перевода строки -> конец файла
+		{20, 12},
+	}
+	for _, tc := range cases {
+		got, err := NextLineStart(r, tc.pos, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("NextLineStart(%d) = %d, want %d", tc.pos, got, tc.want)
+		}
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	content := strings.Repeat("line\n", 30000)
+	r := strings.NewReader(content)
+
+	for _, end := range []int64{0, 5, 7, int64(len(content))} {
+		got, err := CountLines(r, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Count(content[:end], "\n"); got != want {
+			t.Errorf("CountLines(%d) = %d, want %d", end, got, want)
+		}
+	}
+}
+
+func TestSplitBlockMatchesScanner(t *testing.T) {
+	for _, content := range []string{"", "\n", "a\nb\n", "a\r\n\nb", "bad \xff\xfe byte\nc\n"} {
+		var want []string
+		scanner := NewScanner(strings.NewReader(content))
+		for scanner.Scan() {
+			want = append(want, scanner.Text())
+		}
+		if got := SplitBlock([]byte(content)); !slices.Equal(got, want) && len(got)+len(want) > 0 {
+			t.Errorf("%q: got %q, want %q", content, got, want)
+		}
+		if got := SplitBlock(JoinBlock(want)); !slices.Equal(got, want) && len(got)+len(want) > 0 {
+			t.Errorf("%q: join and split: got %q, want %q", content, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package render превращает структурированные совпадения сервера в вывод
+// клиента: строки в формате GNU grep или JSON-строки для программ.
+package render
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	pb "grpc-grep/proto"
+)
+
+// Options задают вид текстового вывода
+type Options struct {
+	FileName     string // имя файла перед строкой (-H); пусто — без имени
+	LineNum      bool   // -n: номер строки перед текстом
+	OnlyMatching bool   // -o: только совпавшие фрагменты
+	Color        bool   // подсвечивать совпадения, номера и разделители
+}
+
+// Цвета GNU grep по умолчанию (GREP_COLORS=ms=01;31:fn=35:ln=32:se=36)
+const (
+	colorMatch     = "01;31"
+	colorFileName  = "35"
+	colorLineNum   = "32"
+	colorSeparator = "36"
+)
+
+// paint оборачивает s в SGR-последовательность так же, как GNU grep:
+// \x1b[K после каждого кода очищает хвост строки, если фон цветной
+func paint(s, sgr string) string {
+	return "\x1b[" + sgr + "m\x1b[K" + s + "\x1b[m\x1b[K"
+}
+
+// Plain возвращает строки вывода для m так, как их печатает GNU grep: имя
+// файла и номер строки отделяются от текста двоеточием у выбранных строк и
+// дефисом у строк контекста. С -o каждый фрагмент печатается отдельной
+// строкой, а строки контекста не печатаются. С Color совпадения из
+// m.Submatches подсвечиваются.
+func Plain(m *pb.Match, opts Options) []string {
+	sep := ":"
+	if m.Context {
+		sep = "-"
+	}
+	if opts.Color {
+		sep = paint(sep, colorSeparator)
+	}
+
+	prefix := ""
+	if opts.FileName != "" {
+		prefix = FileName(opts.FileName, opts) + sep
+	}
+	if opts.LineNum {
+		num := strconv.FormatInt(m.LineNumber, 10)
+		if opts.Color {
+			num = paint(num, colorLineNum)
+		}
+		prefix += num + sep
+	}
+
+	text := string(m.Text)
+	if !opts.OnlyMatching {
+		return []string{prefix + highlight(text, m.Submatches, opts.Color)}
+	}
+	if m.Context {
+		return nil
+	}
+	out := make([]string, 0, len(m.Submatches))
+	for _, sm := range m.Submatches {
+		part := text[sm.Start:sm.End]
+		if opts.Color {
+			part = paint(part, colorMatch)
+		}
+		out = append(out, prefix+part)
+	}
+	return out
+}
+
+// highlight возвращает текст строки, в котором с color подсвечены совпадения
+func highlight(text string, submatches []*pb.Submatch, color bool) string {
+	if !color || len(submatches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	pos := int32(0)
+	for _, sm := range submatches {
+		b.WriteString(text[pos:sm.Start])
+		b.WriteString(paint(text[sm.Start:sm.End], colorMatch))
+		pos = sm.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// FileName возвращает имя файла так, как его печатают -H, -l и -c
+func FileName(name string, opts Options) string {
+	if opts.Color {
+		return paint(name, colorFileName)
+	}
+	return name
+}
+
+// Count возвращает строку -c для файла name; без имени — только число
+func Count(name string, count int, opts Options) string {
+	n := strconv.Itoa(count)
+	if name == "" {
+		return n
+	}
+	sep := ":"
+	if opts.Color {
+		sep = paint(sep, colorSeparator)
+	}
+	return FileName(name, opts) + sep + n
+}
+
+// Separator возвращает строку-разделитель групп контекста sep
+func Separator(sep string, opts Options) string {
+	if opts.Color {
+		return paint(sep, colorSeparator)
+	}
+	return sep
+}
+
+// jsonMatch — одна JSON-строка вывода --json
+type jsonMatch struct {
+	Type       string         `json:"type"` // "match" или "context"
+	File       string         `json:"file,omitempty"`
+	Line       int64          `json:"line"`
+	Offset     int64          `json:"offset"`
+	Text       string         `json:"text"`
+	Submatches []jsonSubmatch `json:"submatches,omitempty"`
+}
+
+type jsonSubmatch struct {
+	Start  int32       `json:"start"`
+	End    int32       `json:"end"`
+	Text   string      `json:"text"`
+	Groups []*jsonSpan `json:"groups,omitempty"` // null у неучаствовавшей группы
+}
+
+type jsonSpan struct {
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+	Text  string `json:"text"`
+}
+
+// JSON кодирует m из файла file одной строкой JSON без перевода строки.
+// Байты не в UTF-8 JSON передать не может, они заменяются на U+FFFD;
+// смещения при этом остаются байтовыми смещениями исходной строки.
+func JSON(file string, m *pb.Match) ([]byte, error) {
+	text := string(m.Text)
+	jm := jsonMatch{
+		Type:   "match",
+		File:   file,
+		Line:   m.LineNumber,
+		Offset: m.ByteOffset,
+		Text:   text,
+	}
+	if m.Context {
+		jm.Type = "context"
+	}
+	for _, sm := range m.Submatches {
+		js := jsonSubmatch{Start: sm.Start, End: sm.End, Text: text[sm.Start:sm.End]}
+		for _, g := range sm.Groups {
+			if g.Start < 0 {
+				js.Groups = append(js.Groups, nil)
+				continue
+			}
+			js.Groups = append(js.Groups, &jsonSpan{Start: g.Start, End: g.End, Text: text[g.Start:g.End]})
+		}
+		jm.Submatches = append(jm.Submatches, js)
+	}
+	return json.Marshal(jm)
+}
+
+// File кодирует имя файла, найденного -l или -L
+func File(name string) ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		File string `json:"file"`
+	}{"file", name})
+}
+
+// Summary кодирует итог -c для --json; file пуст, если итог общий
+func Summary(file string, count int) ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		File  string `json:"file,omitempty"`
+		Count int    `json:"count"`
+	}{"summary", file, count})
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"slices"
+	"testing"
+
+	pb "grpc-grep/proto"
+)
+
+func TestPlain(t *testing.T) {
+	m := &pb.Match{
+		LineNumber: 12,
+		Text:       []byte("a=1 b=2"),
+		Submatches: []*pb.Submatch{{Start: 0, End: 3}, {Start: 4, End: 7}},
+	}
+	cases := []struct {
+		opts Options
+		want []string
+	}{
+		{Options{}, []string{"a=1 b=2"}},
+		{Options{LineNum: true}, []string{"12:a=1 b=2"}},
+		{Options{LineNum: true, OnlyMatching: true}, []string{"12:a=1", "12:b=2"}},
+	}
+	for _, c := range cases {
+		if got := Plain(m, c.opts); !slices.Equal(got, c.want) {
+			t.Errorf("Plain(%+v) = %q, want %q", c.opts, got, c.want)
+		}
+	}
+
+	ctx := &pb.Match{LineNumber: 13, Context: true, Text: []byte("ctx")}
+	if got := Plain(ctx, Options{LineNum: true}); !slices.Equal(got, []string{"13-ctx"}) {
+		t.Errorf("context line = %q", got)
+	}
+	if got := Plain(ctx, Options{OnlyMatching: true}); len(got) != 0 {
+		t.Errorf("context line with -o = %q, want nothing", got)
+	}
+}
+
+func TestPlainColor(t *testing.T) {
+	m := &pb.Match{
+		LineNumber: 7,
+		Text:       []byte("an ERROR here"),
+		Submatches: []*pb.Submatch{{Start: 3, End: 8}},
+	}
+	opts := Options{LineNum: true, Color: true}
+
+	// Байт в байт как grep --color=always -n
+	want := "\x1b[32m\x1b[K7\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[Kan \x1b[01;31m\x1b[KERROR\x1b[m\x1b[K here"
+	if got := Plain(m, opts); len(got) != 1 || got[0] != want {
+		t.Errorf("Plain() = %q\nwant %q", got, want)
+	}
+	if got := Separator("--", opts); got != "\x1b[36m\x1b[K--\x1b[m\x1b[K" {
+		t.Errorf("Separator() = %q", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	m := &pb.Match{
+		LineNumber: 2,
+		ByteOffset: 40,
+		Text:       []byte("k=v"),
+		Submatches: []*pb.Submatch{{
+			Start:  0,
+			End:    3,
+			Groups: []*pb.Span{{Start: 0, End: 1}, {Start: -1, End: -1}},
+		}},
+	}
+	data, err := JSON("", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"match","line":2,"offset":40,"text":"k=v","submatches":[{"start":0,"end":3,"text":"k=v","groups":[{"start":0,"end":1,"text":"k"},null]}]}`
+	if string(data) != want {
+		t.Errorf("JSON = %s\nwant %s", data, want)
+	}
+}
@@ -22,17 +22,45 @@ const (
 )
 
 type GrepRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Lines         []string               `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
-	Pattern       string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
-	After         int32                  `protobuf:"varint,3,opt,name=after,proto3" json:"after,omitempty"`
-	Before        int32                  `protobuf:"varint,4,opt,name=before,proto3" json:"before,omitempty"`
-	CountOnly     bool                   `protobuf:"varint,5,opt,name=count_only,json=countOnly,proto3" json:"count_only,omitempty"`
-	Ignore        bool                   `protobuf:"varint,6,opt,name=ignore,proto3" json:"ignore,omitempty"`
-	Invert        bool                   `protobuf:"varint,7,opt,name=invert,proto3" json:"invert,omitempty"`
-	Fixed         bool                   `protobuf:"varint,8,opt,name=fixed,proto3" json:"fixed,omitempty"`
-	LineNum       bool                   `protobuf:"varint,9,opt,name=line_num,json=lineNum,proto3" json:"line_num,omitempty"`
-	LineOffset    int32                  `protobuf:"varint,10,opt,name=line_offset,json=lineOffset,proto3" json:"line_offset,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Lines      []string               `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	Pattern    string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	After      int32                  `protobuf:"varint,3,opt,name=after,proto3" json:"after,omitempty"`
+	Before     int32                  `protobuf:"varint,4,opt,name=before,proto3" json:"before,omitempty"`
+	CountOnly  bool                   `protobuf:"varint,5,opt,name=count_only,json=countOnly,proto3" json:"count_only,omitempty"`
+	Ignore     bool                   `protobuf:"varint,6,opt,name=ignore,proto3" json:"ignore,omitempty"`
+	Invert     bool                   `protobuf:"varint,7,opt,name=invert,proto3" json:"invert,omitempty"`
+	Fixed      bool                   `protobuf:"varint,8,opt,name=fixed,proto3" json:"fixed,omitempty"`
+	LineNum    bool                   `protobuf:"varint,9,opt,name=line_num,json=lineNum,proto3" json:"line_num,omitempty"`
+	LineOffset int32                  `protobuf:"varint,10,opt,name=line_offset,json=lineOffset,proto3" json:"line_offset,omitempty"`
+	// Строки сообщения — только контекст соседних частей файла: они влияют на
+	// вывод -A/-B, но сами не печатаются и не учитываются в count.
+	Halo bool `protobuf:"varint,11,opt,name=halo,proto3" json:"halo,omitempty"`
+	// Несколько шаблонов, как -e/-f в GNU grep: строка совпадает, если
+	// совпал любой из них. Если список пуст, используется pattern.
+	Patterns []string `protobuf:"bytes,12,rep,name=patterns,proto3" json:"patterns,omitempty"`
+	// -w: совпадение должно быть целым словом
+	Word bool `protobuf:"varint,13,opt,name=word,proto3" json:"word,omitempty"`
+	// -x: совпадение должно занимать всю строку
+	WholeLine bool `protobuf:"varint,14,opt,name=whole_line,json=wholeLine,proto3" json:"whole_line,omitempty"`
+	// -o: печатать только совпавшие фрагменты, каждый отдельной строкой
+	OnlyMatching bool `protobuf:"varint,15,opt,name=only_matching,json=onlyMatching,proto3" json:"only_matching,omitempty"`
+	// -m: остановиться после max_count выбранных строк; 0 — без ограничения.
+	// Сервер ограничивает только свою часть файла, общий лимит по всем
+	// частям соблюдает клиент.
+	MaxCount int32 `protobuf:"varint,16,opt,name=max_count,json=maxCount,proto3" json:"max_count,omitempty"`
+	// Смещение первой строки потока от начала файла в байтах, аналог
+	// line_offset для Match.byte_offset
+	ByteOffset int64 `protobuf:"varint,17,opt,name=byte_offset,json=byteOffset,proto3" json:"byte_offset,omitempty"`
+	// Заполнять Match.submatches. Поиск границ медленнее простой проверки,
+	// поэтому клиент просит их только когда они нужны (--json, подсветка);
+	// с -o границы возвращаются всегда.
+	Submatches bool `protobuf:"varint,18,opt,name=submatches,proto3" json:"submatches,omitempty"`
+	// Строки сообщения одним блоком байт, каждая с '\n' в конце; у последней
+	// строки потока перевода строки может не быть. В отличие от lines, где
+	// каждая строка — отдельное поле и обязана быть корректным UTF-8, блок
+	// переносит любые байты. Если block не пуст, lines игнорируется.
+	Block         []byte `protobuf:"bytes,19,opt,name=block,proto3" json:"block,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -137,10 +165,77 @@ func (x *GrepRequest) GetLineOffset() int32 {
 	return 0
 }
 
+func (x *GrepRequest) GetHalo() bool {
+	if x != nil {
+		return x.Halo
+	}
+	return false
+}
+
+func (x *GrepRequest) GetPatterns() []string {
+	if x != nil {
+		return x.Patterns
+	}
+	return nil
+}
+
+func (x *GrepRequest) GetWord() bool {
+	if x != nil {
+		return x.Word
+	}
+	return false
+}
+
+func (x *GrepRequest) GetWholeLine() bool {
+	if x != nil {
+		return x.WholeLine
+	}
+	return false
+}
+
+func (x *GrepRequest) GetOnlyMatching() bool {
+	if x != nil {
+		return x.OnlyMatching
+	}
+	return false
+}
+
+func (x *GrepRequest) GetMaxCount() int32 {
+	if x != nil {
+		return x.MaxCount
+	}
+	return 0
+}
+
+func (x *GrepRequest) GetByteOffset() int64 {
+	if x != nil {
+		return x.ByteOffset
+	}
+	return 0
+}
+
+func (x *GrepRequest) GetSubmatches() bool {
+	if x != nil {
+		return x.Submatches
+	}
+	return false
+}
+
+func (x *GrepRequest) GetBlock() []byte {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
 type GrepResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Output        []string               `protobuf:"bytes,1,rep,name=output,proto3" json:"output,omitempty"`
-	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Count int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// Выбранные строки и строки контекста в порядке следования в файле
+	Matches []*Match `protobuf:"bytes,4,rep,name=matches,proto3" json:"matches,omitempty"`
+	// Только GrepFile: сколько строк в диапазоне, в последнем ответе потока.
+	// По нему клиент с relative_lines нумерует строки следующих диапазонов.
+	RangeLines    int64 `protobuf:"varint,5,opt,name=range_lines,json=rangeLines,proto3" json:"range_lines,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -175,16 +270,526 @@ func (*GrepResponse) Descriptor() ([]byte, []int) {
 	return file_proto_grep_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *GrepResponse) GetOutput() []string {
+func (x *GrepResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GrepResponse) GetMatches() []*Match {
 	if x != nil {
-		return x.Output
+		return x.Matches
 	}
 	return nil
 }
 
-func (x *GrepResponse) GetCount() int32 {
+func (x *GrepResponse) GetRangeLines() int64 {
 	if x != nil {
-		return x.Count
+		return x.RangeLines
+	}
+	return 0
+}
+
+// Match — строка вывода: выбранная строка или строка контекста -A/-B
+type Match struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Номер строки в файле, начиная с 1, даже без -n. Только у GrepFile с
+	// relative_lines — номер от начала диапазона.
+	LineNumber int64 `protobuf:"varint,1,opt,name=line_number,json=lineNumber,proto3" json:"line_number,omitempty"`
+	// Смещение начала строки от начала файла в байтах
+	ByteOffset int64 `protobuf:"varint,2,opt,name=byte_offset,json=byteOffset,proto3" json:"byte_offset,omitempty"`
+	// Строка контекста -A/-B, а не выбранная строка
+	Context bool `protobuf:"varint,3,opt,name=context,proto3" json:"context,omitempty"`
+	// Текст строки без перевода строки. bytes, а не string: в логах бывают
+	// строки не в UTF-8, а string их не пропустит
+	Text []byte `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	// Непустые совпадения в строке в порядке следования. Заполняются, только
+	// если их запросили, и только там, где шаблон совпал: у выбранных строк,
+	// а с -v — у строк контекста
+	Submatches    []*Submatch `protobuf:"bytes,5,rep,name=submatches,proto3" json:"submatches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Match) Reset() {
+	*x = Match{}
+	mi := &file_proto_grep_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Match) ProtoMessage() {}
+
+func (x *Match) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Match.ProtoReflect.Descriptor instead.
+func (*Match) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Match) GetLineNumber() int64 {
+	if x != nil {
+		return x.LineNumber
+	}
+	return 0
+}
+
+func (x *Match) GetByteOffset() int64 {
+	if x != nil {
+		return x.ByteOffset
+	}
+	return 0
+}
+
+func (x *Match) GetContext() bool {
+	if x != nil {
+		return x.Context
+	}
+	return false
+}
+
+func (x *Match) GetText() []byte {
+	if x != nil {
+		return x.Text
+	}
+	return nil
+}
+
+func (x *Match) GetSubmatches() []*Submatch {
+	if x != nil {
+		return x.Submatches
+	}
+	return nil
+}
+
+// Submatch — совпадение внутри строки, границы в байтах от начала text
+type Submatch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Start int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// Группы захвата по порядку, сквозь все шаблоны запроса; у группы, не
+	// участвовавшей в совпадении, start = end = -1
+	Groups        []*Span `protobuf:"bytes,3,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Submatch) Reset() {
+	*x = Submatch{}
+	mi := &file_proto_grep_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Submatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Submatch) ProtoMessage() {}
+
+func (x *Submatch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Submatch.ProtoReflect.Descriptor instead.
+func (*Submatch) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Submatch) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Submatch) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *Submatch) GetGroups() []*Span {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type Span struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Span) Reset() {
+	*x = Span{}
+	mi := &file_proto_grep_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Span) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Span) ProtoMessage() {}
+
+func (x *Span) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Span.ProtoReflect.Descriptor instead.
+func (*Span) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Span) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Span) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type GrepFileRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Путь относительно корневого каталога сервера (-root)
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Байтовый диапазон [start, end); end = 0 означает конец файла. Диапазону
+	// принадлежат строки, первый байт которых лежит внутри него.
+	Start int64 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	// Параметры поиска; lines, line_offset и halo игнорируются. line_number
+	// и byte_offset в ответе считаются от начала файла.
+	Query *GrepRequest `protobuf:"bytes,4,opt,name=query,proto3" json:"query,omitempty"`
+	// Номер (от нуля) первой строки диапазона после выравнивания, если
+	// вызывающий его знает. Без него сервер считает строки перед диапазоном
+	// сам, читая файл с начала.
+	FirstLine *int64 `protobuf:"varint,5,opt,name=first_line,json=firstLine,proto3,oneof" json:"first_line,omitempty"`
+	// line_number в ответе считается от начала диапазона (первая строка
+	// диапазона — 1), first_line игнорируется. Так ищет клиент, которому
+	// нужны все диапазоны файла: номер первой строки диапазона он узнаёт из
+	// range_lines предыдущих и сдвигает номера сам, а файл с начала никто не
+	// перечитывает. byte_offset и в этом случае считается от начала файла.
+	RelativeLines bool `protobuf:"varint,6,opt,name=relative_lines,json=relativeLines,proto3" json:"relative_lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrepFileRequest) Reset() {
+	*x = GrepFileRequest{}
+	mi := &file_proto_grep_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrepFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrepFileRequest) ProtoMessage() {}
+
+func (x *GrepFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrepFileRequest.ProtoReflect.Descriptor instead.
+func (*GrepFileRequest) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GrepFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GrepFileRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *GrepFileRequest) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *GrepFileRequest) GetQuery() *GrepRequest {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *GrepFileRequest) GetFirstLine() int64 {
+	if x != nil && x.FirstLine != nil {
+		return *x.FirstLine
+	}
+	return 0
+}
+
+func (x *GrepFileRequest) GetRelativeLines() bool {
+	if x != nil {
+		return x.RelativeLines
+	}
+	return false
+}
+
+type StatFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatFileRequest) Reset() {
+	*x = StatFileRequest{}
+	mi := &file_proto_grep_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatFileRequest) ProtoMessage() {}
+
+func (x *StatFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatFileRequest.ProtoReflect.Descriptor instead.
+func (*StatFileRequest) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StatFileRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type StatFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatFileResponse) Reset() {
+	*x = StatFileResponse{}
+	mi := &file_proto_grep_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatFileResponse) ProtoMessage() {}
+
+func (x *StatFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatFileResponse.ProtoReflect.Descriptor instead.
+func (*StatFileResponse) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatFileResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type InfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	mi := &file_proto_grep_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{8}
+}
+
+type InfoResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Число логических процессоров, доступных серверу
+	CpuCount int32 `protobuf:"varint,1,opt,name=cpu_count,json=cpuCount,proto3" json:"cpu_count,omitempty"`
+	// Средняя загрузка системы за минуту; 0, если ОС её не сообщает
+	Load1 float64 `protobuf:"fixed64,2,opt,name=load1,proto3" json:"load1,omitempty"`
+	// Запросы поиска, которые сервер выполняет прямо сейчас
+	ActiveRequests int32 `protobuf:"varint,3,opt,name=active_requests,json=activeRequests,proto3" json:"active_requests,omitempty"`
+	// Сколько строк и байт строк сервер принимает в одном сообщении
+	// GrepRequest; 0 — без ограничения. По ним клиент режет поток на батчи.
+	MaxRequestLines int32 `protobuf:"varint,4,opt,name=max_request_lines,json=maxRequestLines,proto3" json:"max_request_lines,omitempty"`
+	MaxRequestBytes int64 `protobuf:"varint,5,opt,name=max_request_bytes,json=maxRequestBytes,proto3" json:"max_request_bytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	mi := &file_proto_grep_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_grep_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_grep_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *InfoResponse) GetCpuCount() int32 {
+	if x != nil {
+		return x.CpuCount
+	}
+	return 0
+}
+
+func (x *InfoResponse) GetLoad1() float64 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *InfoResponse) GetActiveRequests() int32 {
+	if x != nil {
+		return x.ActiveRequests
+	}
+	return 0
+}
+
+func (x *InfoResponse) GetMaxRequestLines() int32 {
+	if x != nil {
+		return x.MaxRequestLines
+	}
+	return 0
+}
+
+func (x *InfoResponse) GetMaxRequestBytes() int64 {
+	if x != nil {
+		return x.MaxRequestBytes
 	}
 	return 0
 }
@@ -193,7 +798,7 @@ var File_proto_grep_proto protoreflect.FileDescriptor
 
 const file_proto_grep_proto_rawDesc = "" +
 	"\n" +
-	"\x10proto/grep.proto\x12\x04grep\"\x8c\x02\n" +
+	"\x10proto/grep.proto\x12\x04grep\"\x88\x04\n" +
 	"\vGrepRequest\x12\x14\n" +
 	"\x05lines\x18\x01 \x03(\tR\x05lines\x12\x18\n" +
 	"\apattern\x18\x02 \x01(\tR\apattern\x12\x14\n" +
@@ -207,12 +812,70 @@ const file_proto_grep_proto_rawDesc = "" +
 	"\bline_num\x18\t \x01(\bR\alineNum\x12\x1f\n" +
 	"\vline_offset\x18\n" +
 	" \x01(\x05R\n" +
-	"lineOffset\"<\n" +
-	"\fGrepResponse\x12\x16\n" +
-	"\x06output\x18\x01 \x03(\tR\x06output\x12\x14\n" +
-	"\x05count\x18\x02 \x01(\x05R\x05count2<\n" +
+	"lineOffset\x12\x12\n" +
+	"\x04halo\x18\v \x01(\bR\x04halo\x12\x1a\n" +
+	"\bpatterns\x18\f \x03(\tR\bpatterns\x12\x12\n" +
+	"\x04word\x18\r \x01(\bR\x04word\x12\x1d\n" +
+	"\n" +
+	"whole_line\x18\x0e \x01(\bR\twholeLine\x12#\n" +
+	"\ronly_matching\x18\x0f \x01(\bR\fonlyMatching\x12\x1b\n" +
+	"\tmax_count\x18\x10 \x01(\x05R\bmaxCount\x12\x1f\n" +
+	"\vbyte_offset\x18\x11 \x01(\x03R\n" +
+	"byteOffset\x12\x1e\n" +
+	"\n" +
+	"submatches\x18\x12 \x01(\bR\n" +
+	"submatches\x12\x14\n" +
+	"\x05block\x18\x13 \x01(\fR\x05block\"\x87\x01\n" +
+	"\fGrepResponse\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12%\n" +
+	"\amatches\x18\x04 \x03(\v2\v.grep.MatchR\amatches\x12\x1f\n" +
+	"\vrange_lines\x18\x05 \x01(\x03R\n" +
+	"rangeLinesJ\x04\b\x01\x10\x02J\x04\b\x03\x10\x04R\x06outputR\x05lines\"\xa7\x01\n" +
+	"\x05Match\x12\x1f\n" +
+	"\vline_number\x18\x01 \x01(\x03R\n" +
+	"lineNumber\x12\x1f\n" +
+	"\vbyte_offset\x18\x02 \x01(\x03R\n" +
+	"byteOffset\x12\x18\n" +
+	"\acontext\x18\x03 \x01(\bR\acontext\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\fR\x04text\x12.\n" +
+	"\n" +
+	"submatches\x18\x05 \x03(\v2\x0e.grep.SubmatchR\n" +
+	"submatches\"V\n" +
+	"\bSubmatch\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\x12\"\n" +
+	"\x06groups\x18\x03 \x03(\v2\n" +
+	".grep.SpanR\x06groups\".\n" +
+	"\x04Span\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\"\xd0\x01\n" +
+	"\x0fGrepFileRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\x03R\x03end\x12'\n" +
+	"\x05query\x18\x04 \x01(\v2\x11.grep.GrepRequestR\x05query\x12\"\n" +
+	"\n" +
+	"first_line\x18\x05 \x01(\x03H\x00R\tfirstLine\x88\x01\x01\x12%\n" +
+	"\x0erelative_lines\x18\x06 \x01(\bR\rrelativeLinesB\r\n" +
+	"\v_first_line\"%\n" +
+	"\x0fStatFileRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"&\n" +
+	"\x10StatFileResponse\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\"\r\n" +
+	"\vInfoRequest\"\xc2\x01\n" +
+	"\fInfoResponse\x12\x1b\n" +
+	"\tcpu_count\x18\x01 \x01(\x05R\bcpuCount\x12\x14\n" +
+	"\x05load1\x18\x02 \x01(\x01R\x05load1\x12'\n" +
+	"\x0factive_requests\x18\x03 \x01(\x05R\x0eactiveRequests\x12*\n" +
+	"\x11max_request_lines\x18\x04 \x01(\x05R\x0fmaxRequestLines\x12*\n" +
+	"\x11max_request_bytes\x18\x05 \x01(\x03R\x0fmaxRequestBytes2\x98\x02\n" +
 	"\vGrepService\x12-\n" +
-	"\x04Grep\x12\x11.grep.GrepRequest\x1a\x12.grep.GrepResponseB\x17Z\x15grpc-grep/proto;protob\x06proto3"
+	"\x04Grep\x12\x11.grep.GrepRequest\x1a\x12.grep.GrepResponse\x127\n" +
+	"\n" +
+	"GrepStream\x12\x11.grep.GrepRequest\x1a\x12.grep.GrepResponse(\x010\x01\x127\n" +
+	"\bGrepFile\x12\x15.grep.GrepFileRequest\x1a\x12.grep.GrepResponse0\x01\x129\n" +
+	"\bStatFile\x12\x15.grep.StatFileRequest\x1a\x16.grep.StatFileResponse\x12-\n" +
+	"\x04Info\x12\x11.grep.InfoRequest\x1a\x12.grep.InfoResponseB\x17Z\x15grpc-grep/proto;protob\x06proto3"
 
 var (
 	file_proto_grep_proto_rawDescOnce sync.Once
@@ -226,19 +889,39 @@ func file_proto_grep_proto_rawDescGZIP() []byte {
 	return file_proto_grep_proto_rawDescData
 }
 
-var file_proto_grep_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_grep_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_proto_grep_proto_goTypes = []any{
-	(*GrepRequest)(nil),  // 0: grep.GrepRequest
-	(*GrepResponse)(nil), // 1: grep.GrepResponse
+	(*GrepRequest)(nil),      // 0: grep.GrepRequest
+	(*GrepResponse)(nil),     // 1: grep.GrepResponse
+	(*Match)(nil),            // 2: grep.Match
+	(*Submatch)(nil),         // 3: grep.Submatch
+	(*Span)(nil),             // 4: grep.Span
+	(*GrepFileRequest)(nil),  // 5: grep.GrepFileRequest
+	(*StatFileRequest)(nil),  // 6: grep.StatFileRequest
+	(*StatFileResponse)(nil), // 7: grep.StatFileResponse
+	(*InfoRequest)(nil),      // 8: grep.InfoRequest
+	(*InfoResponse)(nil),     // 9: grep.InfoResponse
 }
 var file_proto_grep_proto_depIdxs = []int32{
-	0, // 0: grep.GrepService.Grep:input_type -> grep.GrepRequest
-	1, // 1: grep.GrepService.Grep:output_type -> grep.GrepResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2, // 0: grep.GrepResponse.matches:type_name -> grep.Match
+	3, // 1: grep.Match.submatches:type_name -> grep.Submatch
+	4, // 2: grep.Submatch.groups:type_name -> grep.Span
+	0, // 3: grep.GrepFileRequest.query:type_name -> grep.GrepRequest
+	0, // 4: grep.GrepService.Grep:input_type -> grep.GrepRequest
+	0, // 5: grep.GrepService.GrepStream:input_type -> grep.GrepRequest
+	5, // 6: grep.GrepService.GrepFile:input_type -> grep.GrepFileRequest
+	6, // 7: grep.GrepService.StatFile:input_type -> grep.StatFileRequest
+	8, // 8: grep.GrepService.Info:input_type -> grep.InfoRequest
+	1, // 9: grep.GrepService.Grep:output_type -> grep.GrepResponse
+	1, // 10: grep.GrepService.GrepStream:output_type -> grep.GrepResponse
+	1, // 11: grep.GrepService.GrepFile:output_type -> grep.GrepResponse
+	7, // 12: grep.GrepService.StatFile:output_type -> grep.StatFileResponse
+	9, // 13: grep.GrepService.Info:output_type -> grep.InfoResponse
+	9, // [9:14] is the sub-list for method output_type
+	4, // [4:9] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proto_grep_proto_init() }
@@ -246,13 +929,14 @@ func file_proto_grep_proto_init() {
 	if File_proto_grep_proto != nil {
 		return
 	}
+	file_proto_grep_proto_msgTypes[5].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_grep_proto_rawDesc), len(file_proto_grep_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
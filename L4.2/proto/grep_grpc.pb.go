@@ -19,7 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GrepService_Grep_FullMethodName = "/grep.GrepService/Grep"
+	GrepService_Grep_FullMethodName       = "/grep.GrepService/Grep"
+	GrepService_GrepStream_FullMethodName = "/grep.GrepService/GrepStream"
+	GrepService_GrepFile_FullMethodName   = "/grep.GrepService/GrepFile"
+	GrepService_StatFile_FullMethodName   = "/grep.GrepService/StatFile"
+	GrepService_Info_FullMethodName       = "/grep.GrepService/Info"
 )
 
 // GrepServiceClient is the client API for GrepService service.
@@ -27,6 +31,17 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type GrepServiceClient interface {
 	Grep(ctx context.Context, in *GrepRequest, opts ...grpc.CallOption) (*GrepResponse, error)
+	// Потоковый поиск: клиент шлёт строки батчами, параметры поиска берутся из
+	// первого сообщения, сервер возвращает найденные строки по мере обработки,
+	// а при count_only присылает итоговый count последним сообщением.
+	GrepStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[GrepRequest, GrepResponse], error)
+	// Поиск по файлу на диске сервера: клиент передаёт только путь и байтовый
+	// диапазон, сервер сам читает строки внутри своего корневого каталога.
+	GrepFile(ctx context.Context, in *GrepFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GrepResponse], error)
+	StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*StatFileResponse, error)
+	// Возможности и нагрузка сервера: по ним клиент решает, сколько секций
+	// держать на сервере одновременно.
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
 }
 
 type grepServiceClient struct {
@@ -47,11 +62,74 @@ func (c *grepServiceClient) Grep(ctx context.Context, in *GrepRequest, opts ...g
 	return out, nil
 }
 
+func (c *grepServiceClient) GrepStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[GrepRequest, GrepResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrepService_ServiceDesc.Streams[0], GrepService_GrepStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GrepRequest, GrepResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_GrepStreamClient = grpc.BidiStreamingClient[GrepRequest, GrepResponse]
+
+func (c *grepServiceClient) GrepFile(ctx context.Context, in *GrepFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GrepResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrepService_ServiceDesc.Streams[1], GrepService_GrepFile_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GrepFileRequest, GrepResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_GrepFileClient = grpc.ServerStreamingClient[GrepResponse]
+
+func (c *grepServiceClient) StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*StatFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatFileResponse)
+	err := c.cc.Invoke(ctx, GrepService_StatFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, GrepService_Info_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GrepServiceServer is the server API for GrepService service.
 // All implementations must embed UnimplementedGrepServiceServer
 // for forward compatibility.
 type GrepServiceServer interface {
 	Grep(context.Context, *GrepRequest) (*GrepResponse, error)
+	// Потоковый поиск: клиент шлёт строки батчами, параметры поиска берутся из
+	// первого сообщения, сервер возвращает найденные строки по мере обработки,
+	// а при count_only присылает итоговый count последним сообщением.
+	GrepStream(grpc.BidiStreamingServer[GrepRequest, GrepResponse]) error
+	// Поиск по файлу на диске сервера: клиент передаёт только путь и байтовый
+	// диапазон, сервер сам читает строки внутри своего корневого каталога.
+	GrepFile(*GrepFileRequest, grpc.ServerStreamingServer[GrepResponse]) error
+	StatFile(context.Context, *StatFileRequest) (*StatFileResponse, error)
+	// Возможности и нагрузка сервера: по ним клиент решает, сколько секций
+	// держать на сервере одновременно.
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
 	mustEmbedUnimplementedGrepServiceServer()
 }
 
@@ -65,6 +143,18 @@ type UnimplementedGrepServiceServer struct{}
 func (UnimplementedGrepServiceServer) Grep(context.Context, *GrepRequest) (*GrepResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Grep not implemented")
 }
+func (UnimplementedGrepServiceServer) GrepStream(grpc.BidiStreamingServer[GrepRequest, GrepResponse]) error {
+	return status.Error(codes.Unimplemented, "method GrepStream not implemented")
+}
+func (UnimplementedGrepServiceServer) GrepFile(*GrepFileRequest, grpc.ServerStreamingServer[GrepResponse]) error {
+	return status.Error(codes.Unimplemented, "method GrepFile not implemented")
+}
+func (UnimplementedGrepServiceServer) StatFile(context.Context, *StatFileRequest) (*StatFileResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StatFile not implemented")
+}
+func (UnimplementedGrepServiceServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Info not implemented")
+}
 func (UnimplementedGrepServiceServer) mustEmbedUnimplementedGrepServiceServer() {}
 func (UnimplementedGrepServiceServer) testEmbeddedByValue()                     {}
 
@@ -104,6 +194,60 @@ func _GrepService_Grep_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GrepService_GrepStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GrepServiceServer).GrepStream(&grpc.GenericServerStream[GrepRequest, GrepResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_GrepStreamServer = grpc.BidiStreamingServer[GrepRequest, GrepResponse]
+
+func _GrepService_GrepFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GrepFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GrepServiceServer).GrepFile(m, &grpc.GenericServerStream[GrepFileRequest, GrepResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_GrepFileServer = grpc.ServerStreamingServer[GrepResponse]
+
+func _GrepService_StatFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).StatFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_StatFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).StatFile(ctx, req.(*StatFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GrepService_ServiceDesc is the grpc.ServiceDesc for GrepService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +259,27 @@ var GrepService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Grep",
 			Handler:    _GrepService_Grep_Handler,
 		},
+		{
+			MethodName: "StatFile",
+			Handler:    _GrepService_StatFile_Handler,
+		},
+		{
+			MethodName: "Info",
+			Handler:    _GrepService_Info_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GrepStream",
+			Handler:       _GrepService_GrepStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GrepFile",
+			Handler:       _GrepService_GrepFile_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/grep.proto",
 }
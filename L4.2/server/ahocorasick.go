@@ -0,0 +1,90 @@
+package main
+
+// ahoCorasick ищет сразу много фиксированных строк за один проход по
+// строке, независимо от числа шаблонов. Нужен только факт совпадения,
+// поэтому каждый узел помнит лишь, заканчивается ли в нём (или в его
+// суффиксе) какой-нибудь шаблон.
+type ahoCorasick struct {
+	nodes []acNode
+	// root — переходы из корня: через него проходит почти каждый байт
+	// текста, поэтому для корня хранится полная таблица
+	root [256]int32
+	// empty — среди шаблонов есть пустой, он совпадает с любой строкой
+	empty bool
+}
+
+type acNode struct {
+	next map[byte]int32
+	fail int32
+	out  bool
+}
+
+func newAhoCorasick(patterns []string) *ahoCorasick {
+	ac := &ahoCorasick{nodes: []acNode{{}}}
+
+	// Бор из всех шаблонов
+	for _, p := range patterns {
+		if p == "" {
+			ac.empty = true
+			continue
+		}
+		cur := int32(0)
+		for i := 0; i < len(p); i++ {
+			next, ok := ac.nodes[cur].next[p[i]]
+			if !ok {
+				next = int32(len(ac.nodes))
+				ac.nodes = append(ac.nodes, acNode{})
+				if ac.nodes[cur].next == nil {
+					ac.nodes[cur].next = make(map[byte]int32)
+				}
+				ac.nodes[cur].next[p[i]] = next
+			}
+			cur = next
+		}
+		ac.nodes[cur].out = true
+	}
+
+	// Суффиксные ссылки обходом в ширину: ссылка узла ведёт в самый длинный
+	// собственный суффикс его строки, который тоже есть в боре
+	queue := make([]int32, 0, len(ac.nodes))
+	for b, child := range ac.nodes[0].next {
+		ac.root[b] = child
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for b, child := range ac.nodes[cur].next {
+			ac.nodes[child].fail = ac.step(ac.nodes[cur].fail, b)
+			ac.nodes[child].out = ac.nodes[child].out || ac.nodes[ac.nodes[child].fail].out
+			queue = append(queue, child)
+		}
+	}
+	return ac
+}
+
+// step переходит из узла state по байту b, откатываясь по суффиксным ссылкам
+func (ac *ahoCorasick) step(state int32, b byte) int32 {
+	for state != 0 {
+		if next, ok := ac.nodes[state].next[b]; ok {
+			return next
+		}
+		state = ac.nodes[state].fail
+	}
+	return ac.root[b]
+}
+
+// match сообщает, содержит ли s хотя бы один из шаблонов
+func (ac *ahoCorasick) match(s string) bool {
+	if ac.empty {
+		return true
+	}
+	state := int32(0)
+	for i := 0; i < len(s); i++ {
+		state = ac.step(state, s[i])
+		if ac.nodes[state].out {
+			return true
+		}
+	}
+	return false
+}
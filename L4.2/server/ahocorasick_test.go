@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// randomText собирает строку из маленького алфавита, чтобы шаблоны часто
+// перекрывались и суффиксные ссылки действительно использовались
+func randomText(r *rand.Rand, n int) string {
+	var b strings.Builder
+	for range n {
+		b.WriteByte("abc"[r.IntN(3)])
+	}
+	return b.String()
+}
+
+func TestAhoCorasickMatchesContains(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		patterns := make([]string, 1+r.IntN(8))
+		for i := range patterns {
+			patterns[i] = randomText(r, 1+r.IntN(5))
+		}
+		ac := newAhoCorasick(patterns)
+
+		for range 50 {
+			s := randomText(r, r.IntN(20))
+			want := false
+			for _, p := range patterns {
+				want = want || strings.Contains(s, p)
+			}
+			if got := ac.match(s); got != want {
+				t.Fatalf("patterns %q: match(%q) = %v, want %v", patterns, s, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkFixedPatterns(b *testing.B) {
+	r := rand.New(rand.NewPCG(3, 4))
+	patterns := make([]string, 5000)
+	for i := range patterns {
+		patterns[i] = "ioc-" + randomText(r, 12)
+	}
+	line := "2024-01-01 12:00:00 GET /index.html 200 from 10.0.0.1 agent " + randomText(r, 80)
+
+	b.Run("aho-corasick", func(b *testing.B) {
+		ac := newAhoCorasick(patterns)
+		for b.Loop() {
+			ac.match(line)
+		}
+	})
+	b.Run("contains-loop", func(b *testing.B) {
+		for b.Loop() {
+			for _, p := range patterns {
+				if strings.Contains(line, p) {
+					break
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+
+	"grpc-grep/internal/lineio"
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fileBatchBytes — объём строк, после которого найденное отправляется клиенту
+const fileBatchBytes = 1 << 20
+
+// openFile открывает файл внутри корневого каталога сервера. os.Root не
+// позволяет выйти за пределы корня ни через "..", ни через символические
+// ссылки.
+func (s *server) openFile(path string) (*os.File, int64, error) {
+	if s.root == nil {
+		return nil, 0, status.Error(codes.FailedPrecondition, "file access is disabled, start the server with -root")
+	}
+
+	f, err := s.root.Open(path)
+	if err != nil {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil, 0, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, fs.ErrPermission):
+			return nil, 0, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, status.Error(codes.Internal, err.Error())
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, 0, status.Errorf(codes.InvalidArgument, "%s is a directory", path)
+	}
+	return f, info.Size(), nil
+}
+
+func (s *server) StatFile(ctx context.Context, req *pb.StatFileRequest) (*pb.StatFileResponse, error) {
+	f, size, err := s.openFile(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	return &pb.StatFileResponse{Size: size}, nil
+}
+
+func (s *server) GrepFile(req *pb.GrepFileRequest, stream pb.GrepService_GrepFileServer) error {
+	done, err := s.admit()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if req.Query == nil {
+		return status.Error(codes.InvalidArgument, "query is required")
+	}
+
+	f, size, err := s.openFile(req.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	end := req.End
+	if end <= 0 || end > size {
+		end = size
+	}
+	if req.Start < 0 || req.Start > end {
+		return status.Errorf(codes.OutOfRange, "invalid range [%d, %d) for file of %d bytes", req.Start, end, size)
+	}
+
+	// Диапазону принадлежат строки, которые начинаются внутри него, поэтому
+	// обе границы сдвигаются к началу следующей строки
+	start, err := lineio.NextLineStart(f, req.Start, size)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	end, err = lineio.NextLineStart(f, end, size)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	opts := optionsFromRequest(req.Query)
+
+	// Halo-строки сервер читает сам, как это делает клиент для GrepStream
+	var lead, trail []string
+	if !opts.countOnly {
+		if lead, err = lineio.LinesBefore(f, start, opts.after); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if trail, err = lineio.LinesAfter(f, end, opts.before); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	// Номер первой строки диапазона в файле: от вызывающего, а если он его
+	// не знает — подсчётом с начала файла. С relative_lines строки
+	// нумеруются от начала диапазона, и читать файл с начала не нужно.
+	first := 0
+	switch {
+	case req.RelativeLines:
+	case req.FirstLine != nil:
+		if req.GetFirstLine() < 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid first line %d", req.GetFirstLine())
+		}
+		first = int(req.GetFirstLine())
+	default:
+		if first, err = lineio.CountLines(f, start); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	opts.lineOffset = first - len(lead)
+	opts.byteOffset = start - lineio.Size(lead)
+
+	g, err := s.newGrepper(req.Query, opts)
+	if err != nil {
+		return err
+	}
+
+	send := func(out []*pb.Match) error {
+		if len(out) == 0 {
+			return nil
+		}
+		return stream.Send(&pb.GrepResponse{Matches: out})
+	}
+
+	if err := send(g.feed(lead, true)); err != nil {
+		return err
+	}
+	body := &lineCounter{r: io.NewSectionReader(f, start, end-start)}
+	if err := feedReader(stream.Context(), g, body, send); err != nil {
+		return err
+	}
+	if err := send(g.feed(trail, true)); err != nil {
+		return err
+	}
+
+	// После -m чтение обрывается раньше конца диапазона: остаток строк
+	// досчитывается без поиска
+	rest, err := lineio.CountLines(io.NewSectionReader(f, start+body.read, end-start-body.read), end-start-body.read)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	last := &pb.GrepResponse{RangeLines: int64(body.lines + rest)}
+	if opts.countOnly {
+		last.Count = int32(g.count)
+	}
+	return stream.Send(last)
+}
+
+// lineCounter считает байты и переводы строк, прочитанные через него
+type lineCounter struct {
+	r     io.Reader
+	read  int64
+	lines int
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	c.lines += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
+
+// feedReader читает строки из r батчами по fileBatchBytes и передаёт
+// найденное в send. Чтение прекращается, как только достигнут лимит -m.
+func feedReader(ctx context.Context, g *grepper, r io.Reader, send func([]*pb.Match) error) error {
+	scanner := lineio.NewScanner(r)
+
+	var batch []string
+	size := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		batch = append(batch, line)
+		size += len(line)
+
+		if size >= fileBatchBytes {
+			if err := ctx.Err(); err != nil {
+				return status.FromContextError(err).Err()
+			}
+			if err := send(g.feed(batch, false)); err != nil {
+				return err
+			}
+			if g.done() {
+				return nil
+			}
+			batch = batch[:0]
+			size = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return send(g.feed(batch, false))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// collectStream собирает ответы GrepFile без сети
+type collectStream struct {
+	grpc.ServerStream
+	out   []*pb.Match
+	count int32
+	lines int64
+}
+
+func (s *collectStream) Send(resp *pb.GrepResponse) error {
+	s.out = append(s.out, resp.Matches...)
+	s.count += resp.Count
+	s.lines += resp.RangeLines
+	return nil
+}
+
+func (s *collectStream) Context() context.Context { return context.Background() }
+
+func newRootServer(t *testing.T, files map[string]string) *server {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { root.Close() })
+	return &server{root: root}
+}
+
+func TestGrepFileRangesMatchWhole(t *testing.T) {
+	query := &pb.GrepRequest{Pattern: "match", After: 1, Before: 2, LineNum: true}
+	opts := optionsFromRequest(query)
+
+	want, _, err := GrepLines(boundaryLines, patternsFromRequest(query), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// В CRLF-файле смещения учитывают и '\r' предыдущих строк
+	for _, eol := range []string{"\n", "\r\n"} {
+		content := strings.Join(boundaryLines, eol) + eol
+		s := newRootServer(t, map[string]string{"log.txt": content})
+		// Диапазоны режут строки посередине: сервер сам выравнивает границы
+		size := int64(len(content))
+		// Номер первой строки диапазона сервер считает сам, берёт из
+		// first_line или, с relative_lines, оставляет сдвиг клиенту
+		for _, mode := range []string{"counted", "first_line", "relative_lines"} {
+			for n := 1; n <= 9; n++ {
+				var got []*pb.Match
+				var first int64
+				for i := 0; i < n; i++ {
+					req := &pb.GrepFileRequest{
+						Path:  "log.txt",
+						Start: size * int64(i) / int64(n),
+						End:   size * int64(i+1) / int64(n),
+						Query: query,
+					}
+					switch mode {
+					case "first_line":
+						req.FirstLine = proto.Int64(first)
+					case "relative_lines":
+						req.RelativeLines = true
+					}
+					stream := &collectStream{}
+					if err := s.GrepFile(req, stream); err != nil {
+						t.Fatal(err)
+					}
+					if req.RelativeLines {
+						for _, m := range stream.out {
+							m.LineNumber += first
+						}
+					}
+					got = append(got, stream.out...)
+					first += stream.lines
+				}
+				if first != int64(len(boundaryLines)) {
+					t.Errorf("%q, %s, %d ranges: range_lines sum to %d, want %d", eol, mode, n, first, len(boundaryLines))
+				}
+				if lines := plainLines(got, opts); !slices.Equal(lines, want) {
+					t.Errorf("%q, %s, %d ranges: got %q\nwant %q", eol, mode, n, lines, want)
+				}
+				// Смещения считаются от начала файла, а не от начала диапазона
+				for _, m := range got {
+					if !strings.HasPrefix(content[m.ByteOffset:], string(m.Text)+eol) {
+						t.Errorf("%q, %s, %d ranges: line %d at offset %d is not %q", eol, mode, n, m.LineNumber, m.ByteOffset, m.Text)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGrepFileCountsLinesAfterLimit(t *testing.T) {
+	// Больше fileBatchBytes, чтобы сервер бросил чтение до конца диапазона
+	const numLines = 200_000
+	var content strings.Builder
+	for i := range numLines {
+		fmt.Fprintf(&content, "match line %06d\n", i)
+	}
+	s := newRootServer(t, map[string]string{"log.txt": content.String()})
+
+	// После -m сервер не ищет дальше, но строки диапазона считает все
+	stream := &collectStream{}
+	err := s.GrepFile(&pb.GrepFileRequest{Path: "log.txt", Query: &pb.GrepRequest{Pattern: "match", MaxCount: 1}}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.out) != 1 || stream.lines != numLines {
+		t.Errorf("got %d matches and %d lines, want 1 and %d", len(stream.out), stream.lines, numLines)
+	}
+}
+
+func TestGrepFileStaysInsideRoot(t *testing.T) {
+	s := newRootServer(t, map[string]string{"log.txt": "x\n"})
+
+	cases := map[string]codes.Code{
+		"../etc/passwd": codes.InvalidArgument,
+		"/etc/passwd":   codes.InvalidArgument,
+		"missing.txt":   codes.NotFound,
+	}
+	for path, want := range cases {
+		err := s.GrepFile(&pb.GrepFileRequest{Path: path, Query: &pb.GrepRequest{Pattern: "x"}}, &collectStream{})
+		if status.Code(err) != want {
+			t.Errorf("%s: code = %v, want %v (%v)", path, status.Code(err), want, err)
+		}
+	}
+
+	disabled := &server{}
+	_, err := disabled.StatFile(context.Background(), &pb.StatFileRequest{Path: "log.txt"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("without -root: code = %v, want FailedPrecondition", status.Code(err))
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"grpc-grep/internal/lineio"
+	"grpc-grep/internal/render"
+	pb "grpc-grep/proto"
+)
+
+type Options struct {
+	after        int
+	before       int
+	countOnly    bool
+	ignore       bool
+	invert       bool
+	fixed        bool
+	lineNum      bool
+	lineOffset   int
+	word         bool
+	wholeLine    bool
+	onlyMatching bool
+	maxCount     int   // 0 — без ограничения
+	byteOffset   int64 // смещение первой строки потока в файле
+	submatches   bool  // нужны границы совпадений и групп
+}
+
+// matcher проверяет строки на совпадение и при необходимости находит
+// границы совпадений
+type matcher struct {
+	match func(s string) bool
+	// spans возвращает совпадения в порядке следования в формате
+	// regexp.FindAllStringSubmatchIndex: границы совпадения, затем границы
+	// каждой группы; nil, если границы не запрошены
+	spans func(s string) [][]int
+}
+
+// compilePattern подготавливает функцию проверки строки. Строка совпадает,
+// если совпал любой из шаблонов.
+func compilePattern(patterns []string, opts Options) (matcher, error) {
+	if len(patterns) == 0 {
+		return matcher{}, errors.New("no pattern given")
+	}
+	// Для -w, -o и границ совпадений нужен regexp
+	needSpans := opts.onlyMatching || opts.submatches
+	if opts.fixed && !opts.word && !needSpans {
+		if opts.wholeLine {
+			return matcher{match: compileFixedLine(patterns, opts.ignore)}, nil
+		}
+		return matcher{match: compileFixed(patterns, opts.ignore)}, nil
+	}
+
+	// Шаблоны проверяются по отдельности, чтобы ошибка указывала на
+	// конкретный шаблон, а затем объединяются в одно выражение
+	alts := make([]string, len(patterns))
+	for i, p := range patterns {
+		if opts.fixed {
+			p = regexp.QuoteMeta(p)
+		} else if _, err := syntax.Parse(p, syntax.Perl); err != nil {
+			return matcher{}, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		alts[i] = "(?:" + p + ")"
+	}
+	expr := strings.Join(alts, "|")
+	if opts.wholeLine {
+		expr = "^(?:" + expr + ")$"
+	}
+	if opts.ignore {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return matcher{}, err
+	}
+	// Как и GNU grep, из совпадений с одного места берётся самое длинное,
+	// а не первая подошедшая альтернатива
+	re.Longest()
+
+	m := matcher{
+		match: re.MatchString,
+		spans: func(s string) [][]int {
+			return re.FindAllStringSubmatchIndex(s, -1)
+		},
+	}
+	if opts.word && !opts.wholeLine {
+		// exact проверяет, что шаблон совпадает со всей строкой: по нему
+		// ищутся более короткие совпадения с того же места
+		exact := regexp.MustCompile("^(?:" + expr + ")$")
+		m.match = func(s string) bool {
+			return len(wordSpans(re, exact, s, 1)) > 0
+		}
+		m.spans = func(s string) [][]int {
+			return wordSpans(re, exact, s, -1)
+		}
+	}
+	if !needSpans {
+		m.spans = nil
+	}
+	return m, nil
+}
+
+// wordSpans возвращает до n совпадений re в s, которые, как в GNU grep -w,
+// не примыкают к буквам, цифрам и подчёркиванию; n < 0 — все совпадения.
+// Если самое длинное совпадение примыкает к слову, как и в GNU, с того же
+// места пробуются более короткие.
+func wordSpans(re, exact *regexp.Regexp, s string, n int) [][]int {
+	var spans [][]int
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		if n >= 0 && len(spans) == n {
+			break
+		}
+		if !isWord(s, loc[0], loc[1]) {
+			if loc = shorterWord(exact, s, loc[0], loc[1]); loc == nil {
+				continue
+			}
+		}
+		spans = append(spans, loc)
+	}
+	return spans
+}
+
+// shorterWord ищет самое длинное совпадение exact с s[start:end'] при
+// end' < end, которое не примыкает к словам, и возвращает его границы в
+// s; nil — такого нет
+func shorterWord(exact *regexp.Regexp, s string, start, end int) []int {
+	for e := end - 1; e > start; e-- {
+		if !utf8.RuneStart(s[e]) || !isWord(s, start, e) {
+			continue
+		}
+		loc := exact.FindStringSubmatchIndex(s[start:e])
+		if loc == nil {
+			continue
+		}
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += start
+			}
+		}
+		return loc
+	}
+	return nil
+}
+
+// isWord сообщает, что s[start:end] не примыкает к буквам, цифрам и
+// подчёркиванию
+func isWord(s string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(s[:start])
+	after, _ := utf8.DecodeRuneInString(s[end:])
+	return (start == 0 || !isWordRune(before)) && (end == len(s) || !isWordRune(after))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// compileFixedLine для -F -x сравнивает строку целиком с набором шаблонов
+func compileFixedLine(patterns []string, ignore bool) func(string) bool {
+	set := make(map[string]struct{}, len(patterns))
+	for _, p := range patterns {
+		if ignore {
+			p = strings.ToLower(p)
+		}
+		set[p] = struct{}{}
+	}
+	return func(s string) bool {
+		if ignore {
+			s = strings.ToLower(s)
+		}
+		_, ok := set[s]
+		return ok
+	}
+}
+
+// compileFixed ищет фиксированные строки: одну — через strings.Contains,
+// несколько — автоматом Ахо-Корасик за один проход по строке
+func compileFixed(patterns []string, ignore bool) func(string) bool {
+	if ignore {
+		lowered := make([]string, len(patterns))
+		for i, p := range patterns {
+			lowered[i] = strings.ToLower(p)
+		}
+		match := compileFixed(lowered, false)
+		return func(s string) bool {
+			return match(strings.ToLower(s))
+		}
+	}
+
+	if len(patterns) == 1 {
+		pattern := patterns[0]
+		return func(s string) bool {
+			return strings.Contains(s, pattern)
+		}
+	}
+	return newAhoCorasick(patterns).match
+}
+
+// numberedLine — строка вместе с её номером от начала потока
+type numberedLine struct {
+	num    int
+	offset int64 // смещение начала строки от начала потока
+	text   string
+	halo   bool // строка соседней части файла, сама не печатается
+}
+
+// grepper хранит состояние поиска между батчами строк, поэтому контекст
+// -A/-B не теряется на границе батчей
+type grepper struct {
+	matcher
+	opts Options
+
+	lineNo    int            // номер следующей строки от начала потока
+	offset    int64          // смещение следующей строки от начала потока
+	pending   []numberedLine // последние ненапечатанные строки для -B
+	afterLeft int            // сколько строк ещё напечатать для -A
+	count     int
+
+	workers *workerPool
+}
+
+func newGrepper(patterns []string, opts Options) (*grepper, error) {
+	m, err := compilePattern(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Как и GNU grep, с -o строки контекста не печатаются
+	if opts.onlyMatching {
+		opts.after, opts.before = 0, 0
+	}
+	return &grepper{matcher: m, opts: opts}, nil
+}
+
+// full сообщает, что выбрано уже -m строк и новые совпадения не нужны
+func (g *grepper) full() bool {
+	return g.opts.maxCount > 0 && g.count >= g.opts.maxCount
+}
+
+// done сообщает, что после -m строк напечатан и их контекст -A: дальше
+// поток можно не читать
+func (g *grepper) done() bool {
+	return g.full() && g.afterLeft == 0
+}
+
+// matchLines параллельно проверяет строки на общем пуле горутин и
+// возвращает флаги совпадений
+func (g *grepper) matchLines(lines []string) []bool {
+	matched := make([]bool, len(lines))
+	g.workers.each(len(lines), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			matched[i] = g.match(lineio.TrimCR(lines[i])) != g.opts.invert
+		}
+	})
+	return matched
+}
+
+// feed обрабатывает очередной батч и возвращает строки, которые уже можно
+// напечатать. Строки, ожидающие решения по -B, остаются в g.pending.
+// Для halo-батча совпадения только открывают контекст для своих строк:
+// сами halo-строки не печатаются и не считаются.
+func (g *grepper) feed(lines []string, halo bool) []*pb.Match {
+	if g.done() {
+		return nil
+	}
+	matched := g.matchLines(lines)
+
+	var out []*pb.Match
+	for i, line := range lines {
+		l := numberedLine{num: g.lineNo, offset: g.offset, text: lineio.TrimCR(line), halo: halo}
+		g.lineNo++
+		g.offset += int64(len(line)) + 1
+
+		// После -m строк, как в GNU grep, печатается только хвост -A,
+		// даже если в нём есть совпадения
+		if g.full() {
+			if g.afterLeft == 0 {
+				break
+			}
+			g.afterLeft--
+			if !halo {
+				out = append(out, g.format(l, true))
+			}
+			continue
+		}
+
+		switch {
+		case matched[i]:
+			if !halo {
+				g.count++
+			}
+			if g.opts.countOnly {
+				continue
+			}
+			for _, p := range g.pending {
+				if !p.halo {
+					out = append(out, g.format(p, true))
+				}
+			}
+			g.pending = g.pending[:0]
+			if !halo {
+				out = append(out, g.format(l, false))
+			}
+			g.afterLeft = g.opts.after
+
+		case g.afterLeft > 0:
+			g.afterLeft--
+			if !halo {
+				out = append(out, g.format(l, true))
+			}
+
+		case g.opts.before > 0:
+			if len(g.pending) == g.opts.before {
+				g.pending = append(g.pending[:0], g.pending[1:]...)
+			}
+			g.pending = append(g.pending, l)
+		}
+	}
+
+	return out
+}
+
+// format описывает строку для вывода: номер и смещение считаются от начала
+// файла, печатает строку уже клиент. Границы совпадений, если они
+// запрошены, добавляются к строкам, где шаблон действительно совпал: к
+// выбранным строкам, а с -v — к строкам контекста. Так же GNU grep решает,
+// что подсвечивать.
+func (g *grepper) format(l numberedLine, context bool) *pb.Match {
+	m := &pb.Match{
+		LineNumber: int64(g.opts.lineOffset + l.num + 1),
+		ByteOffset: g.opts.byteOffset + l.offset,
+		Context:    context,
+		Text:       []byte(l.text),
+	}
+	if g.spans == nil || context != g.opts.invert {
+		return m
+	}
+
+	for _, loc := range g.spans(l.text) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		sm := &pb.Submatch{Start: int32(loc[0]), End: int32(loc[1])}
+		for i := 2; i+1 < len(loc); i += 2 {
+			sm.Groups = append(sm.Groups, &pb.Span{Start: int32(loc[i]), End: int32(loc[i+1])})
+		}
+		m.Submatches = append(m.Submatches, sm)
+	}
+	return m
+}
+
+func GrepLines(
+	lines []string,
+	patterns []string,
+	opts Options,
+) ([]string, int, error) {
+	g, err := newGrepper(patterns, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	out := g.feed(lines, false)
+
+	if opts.countOnly {
+		return nil, g.count, nil
+	}
+	return plainLines(out, opts), 0, nil
+}
+
+// plainLines печатает совпадения так, как их напечатал бы клиент
+func plainLines(out []*pb.Match, opts Options) []string {
+	var lines []string
+	for _, m := range out {
+		lines = append(lines, render.Plain(m, render.Options{LineNum: opts.lineNum, OnlyMatching: opts.onlyMatching})...)
+	}
+	return lines
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// boundaryLines — строки, в которых совпадения стоят у самых границ частей
+// и их контексты перекрываются при любом разбиении
+var boundaryLines = []string{
+	"match first",
+	"a",
+	"b",
+	"match",
+	"c",
+	"match",
+	"d",
+	"e",
+	"f",
+	"g",
+	"h",
+	"match",
+	"",
+	"i",
+	"match last",
+}
+
+// grepSplit имитирует распределённый поиск: делит строки на n частей,
+// добавляет к каждой halo-строки соседей и склеивает вывод частей
+func grepSplit(t *testing.T, lines []string, pattern string, opts Options, n int) ([]string, int) {
+	t.Helper()
+
+	size := (len(lines) + n - 1) / n
+	var out []string
+	count := 0
+	for start := 0; start < len(lines); start += size {
+		end := min(start+size, len(lines))
+		lead := lines[max(start-opts.after, 0):start]
+		trail := lines[end:min(end+opts.before, len(lines))]
+
+		partOpts := opts
+		partOpts.lineOffset = start - len(lead)
+		g, err := newGrepper([]string{pattern}, partOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, plainLines(g.feed(lead, true), opts)...)
+		out = append(out, plainLines(g.feed(lines[start:end], false), opts)...)
+		out = append(out, plainLines(g.feed(trail, true), opts)...)
+		count += g.count
+	}
+	return out, count
+}
+
+// gnuGrep запускает системный grep, если он установлен
+func gnuGrep(t *testing.T, lines []string, pattern string, opts Options) []string {
+	t.Helper()
+
+	bin, err := exec.LookPath("grep")
+	if err != nil {
+		t.Skip("grep is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--no-group-separator", "-A", strconv.Itoa(opts.after), "-B", strconv.Itoa(opts.before)}
+	if opts.lineNum {
+		args = append(args, "-n")
+	}
+	if opts.invert {
+		args = append(args, "-v")
+	}
+	if opts.ignore {
+		args = append(args, "-i")
+	}
+	if opts.fixed {
+		args = append(args, "-F")
+	} else {
+		args = append(args, "-E")
+	}
+	if opts.word {
+		args = append(args, "-w")
+	}
+	if opts.wholeLine {
+		args = append(args, "-x")
+	}
+	if opts.onlyMatching {
+		args = append(args, "-o")
+	}
+	if opts.maxCount > 0 {
+		args = append(args, "-m", strconv.Itoa(opts.maxCount))
+	}
+	out, err := exec.Command(bin, append(args, pattern, path)...).Output()
+	if err != nil && len(out) > 0 {
+		t.Fatalf("grep failed: %v", err)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+func TestGrepLinesContext(t *testing.T) {
+	out, _, err := GrepLines(boundaryLines, []string{"^match$"}, Options{after: 1, before: 2, lineNum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"2-a", "3-b", "4:match", "5-c", "6:match", "7-d",
+		"10-g", "11-h", "12:match", "13-",
+	}
+	if !slices.Equal(out, want) {
+		t.Errorf("GrepLines() = %q\nwant %q", out, want)
+	}
+}
+
+func TestGrepSplitMatchesWhole(t *testing.T) {
+	cases := []Options{
+		{lineNum: true},
+		{after: 1, lineNum: true},
+		{before: 3, lineNum: true},
+		{after: 2, before: 2, lineNum: true},
+		{after: 20, before: 20},
+		{after: 1, before: 1, invert: true, lineNum: true},
+	}
+
+	for _, opts := range cases {
+		want, _, err := GrepLines(boundaryLines, []string{"match"}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Любое разбиение, вплоть до одной строки на часть, даёт тот же вывод
+		for n := 1; n <= len(boundaryLines); n++ {
+			got, _ := grepSplit(t, boundaryLines, "match", opts, n)
+			if !slices.Equal(got, want) {
+				t.Errorf("opts %+v, %d parts: got %q\nwant %q", opts, n, got, want)
+			}
+		}
+	}
+}
+
+func TestGrepSplitCount(t *testing.T) {
+	opts := Options{after: 3, before: 3, countOnly: true}
+	for n := 1; n <= len(boundaryLines); n++ {
+		if _, count := grepSplit(t, boundaryLines, "match", opts, n); count != 5 {
+			t.Errorf("%d parts: count = %d, want 5", n, count)
+		}
+	}
+}
+
+func TestGrepSplitMatchesGNU(t *testing.T) {
+	cases := []Options{
+		{after: 1, lineNum: true},
+		{before: 2, lineNum: true},
+		{after: 2, before: 3, lineNum: true},
+		{after: 1, before: 1},
+		{after: 1, before: 2, invert: true, lineNum: true},
+	}
+
+	for _, opts := range cases {
+		want := gnuGrep(t, boundaryLines, "match", opts)
+		for _, n := range []int{1, 2, 3, 4, 7, len(boundaryLines)} {
+			got, _ := grepSplit(t, boundaryLines, "match", opts, n)
+			if !slices.Equal(got, want) {
+				t.Errorf("opts %+v, %d parts: got %q\nGNU grep %q", opts, n, got, want)
+			}
+		}
+	}
+}
+
+func TestCompilePatternMultiple(t *testing.T) {
+	lines := []string{"ERROR disk", "warn: Timeout", "info", "a|b", ""}
+	cases := []struct {
+		patterns []string
+		opts     Options
+		want     []bool
+	}{
+		{[]string{"ERROR", "timeout"}, Options{}, []bool{true, false, false, false, false}},
+		{[]string{"ERROR", "timeout"}, Options{ignore: true}, []bool{true, true, false, false, false}},
+		{[]string{"^info$", "^$"}, Options{}, []bool{false, false, true, false, true}},
+		// Шаблоны объединяются по отдельности: "|" внутри -F не оператор
+		{[]string{"a|b", "disk"}, Options{fixed: true}, []bool{true, false, false, true, false}},
+		{[]string{"TIMEOUT", "nothing"}, Options{fixed: true, ignore: true}, []bool{false, true, false, false, false}},
+		{[]string{"absent", ""}, Options{fixed: true}, []bool{true, true, true, true, true}},
+	}
+
+	for _, c := range cases {
+		m, err := compilePattern(c.patterns, c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, line := range lines {
+			if got := m.match(line); got != c.want[i] {
+				t.Errorf("%q %+v: match(%q) = %v, want %v", c.patterns, c.opts, line, got, c.want[i])
+			}
+		}
+	}
+
+	if _, err := compilePattern([]string{"ok", "a)|(b"}, Options{}); err == nil {
+		t.Error("unbalanced pattern compiled without error")
+	}
+}
+
+// modeLines проверяют -w, -x и -o: слова на границах, соседние совпадения,
+// совпадения внутри других слов
+var modeLines = []string{
+	"foo",
+	"foo foo",
+	"foobar foo_bar",
+	"bar-foo, foo.",
+	"nothing here",
+	"xfoo foox foo",
+	"FOO",
+	"fo",
+	"foo bar foo",
+	"abc",
+	"abcd ab",
+	"foo barx",
+}
+
+func TestGrepModesMatchGNU(t *testing.T) {
+	cases := []struct {
+		pattern string
+		opts    Options
+	}{
+		{"foo", Options{word: true, lineNum: true}},
+		{"foo", Options{word: true, onlyMatching: true, lineNum: true}},
+		{"fo+", Options{onlyMatching: true}},
+		{"foo", Options{wholeLine: true, lineNum: true}},
+		{"foo.*", Options{wholeLine: true, ignore: true}},
+		{"foo", Options{fixed: true, word: true}},
+		{"FOO", Options{fixed: true, wholeLine: true, ignore: true, lineNum: true}},
+		{"foo", Options{maxCount: 2, lineNum: true}},
+		{"foo", Options{maxCount: 1, after: 3, lineNum: true}},
+		{"foo", Options{maxCount: 2, invert: true, lineNum: true}},
+		{"foo", Options{maxCount: 3, word: true, onlyMatching: true}},
+		{"o", Options{onlyMatching: true, invert: true}},
+		// Как в GNU, из совпадений с одного места берётся самое длинное, а
+		// с -w, если оно примыкает к слову, пробуются более короткие
+		{"ab|abc", Options{word: true, lineNum: true}},
+		{"ab|abc", Options{onlyMatching: true, lineNum: true}},
+		{"ab|abc", Options{word: true, onlyMatching: true}},
+		{"foo|foo bar", Options{word: true, onlyMatching: true, lineNum: true}},
+		{"fo|foo|foo bar", Options{onlyMatching: true}},
+	}
+
+	for _, c := range cases {
+		want := gnuGrep(t, modeLines, c.pattern, c.opts)
+		got, _, err := GrepLines(modeLines, []string{c.pattern}, c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q %+v: got %q\nGNU grep %q", c.pattern, c.opts, got, want)
+		}
+
+		// Без -m разбиение на части не меняет вывод
+		if c.opts.maxCount > 0 {
+			continue
+		}
+		for _, n := range []int{2, 3, len(modeLines)} {
+			if split, _ := grepSplit(t, modeLines, c.pattern, c.opts, n); !slices.Equal(split, want) {
+				t.Errorf("%q %+v, %d parts: got %q\nGNU grep %q", c.pattern, c.opts, n, split, want)
+			}
+		}
+	}
+}
+
+func TestGrepMaxCountStopsEarly(t *testing.T) {
+	g, err := newGrepper([]string{"match"}, Options{maxCount: 2, after: 1, lineNum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := plainLines(g.feed(boundaryLines[:4], false), g.opts)
+	if g.done() {
+		t.Fatal("done before the trailing context was printed")
+	}
+	out = append(out, plainLines(g.feed(boundaryLines[4:], false), g.opts)...)
+	if !g.done() {
+		t.Error("not done after the limit and its context")
+	}
+
+	want := []string{"1:match first", "2-a", "4:match", "5-c"}
+	if !slices.Equal(out, want) {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if g.count != 2 {
+		t.Errorf("count = %d, want 2", g.count)
+	}
+}
+
+func TestGrepperBatchesMatchSingleFeed(t *testing.T) {
+	// Строки с '\r' проверяют, что смещения копятся по длине строки в файле
+	lines := slices.Clone(boundaryLines)
+	for i := 1; i < len(lines); i += 3 {
+		lines[i] += "\r"
+	}
+	cases := []Options{
+		{after: 1, before: 2, lineNum: true},
+		{after: 20, before: 20},
+		{after: 1, before: 1, invert: true, lineNum: true},
+		{after: 3, maxCount: 2, lineNum: true},
+		{before: 2, maxCount: 4, invert: true},
+		{onlyMatching: true, submatches: true, byteOffset: 7},
+		{countOnly: true},
+	}
+
+	feed := func(opts Options, size int) ([]*pb.Match, int) {
+		g, err := newGrepper([]string{"match"}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []*pb.Match
+		for start := 0; start < len(lines); start += size {
+			out = append(out, g.feed(lines[start:min(start+size, len(lines))], false)...)
+		}
+		return out, g.count
+	}
+
+	for _, opts := range cases {
+		want, wantCount := feed(opts, len(lines))
+		// Батчи любого размера, вплоть до одной строки, дают тот же вывод,
+		// что и один вызов feed со всеми строками
+		for size := 1; size < len(lines); size++ {
+			got, count := feed(opts, size)
+			if !slices.EqualFunc(got, want, func(a, b *pb.Match) bool { return proto.Equal(a, b) }) {
+				t.Errorf("opts %+v, batches of %d: got %v\nwant %v", opts, size, got, want)
+			}
+			if count != wantCount {
+				t.Errorf("opts %+v, batches of %d: count = %d, want %d", opts, size, count, wantCount)
+			}
+		}
+	}
+}
+
+func TestGrepSubmatches(t *testing.T) {
+	opts := Options{submatches: true, byteOffset: 100}
+	g, err := newGrepper([]string{`(\w+)=(\d+)?`, `#(x)`}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := g.feed([]string{"skip", "a=1 b= #x"}, false)
+	if len(out) != 1 {
+		t.Fatalf("got %d matches, want 1", len(out))
+	}
+	m := out[0]
+	if m.LineNumber != 2 || m.ByteOffset != 105 || m.Context {
+		t.Errorf("line %d, offset %d, context %v; want line 2, offset 105, match", m.LineNumber, m.ByteOffset, m.Context)
+	}
+
+	// Группы идут сквозь оба шаблона; неучаствовавшие группы равны -1
+	type span = [2]int32
+	want := [][]span{
+		{{0, 3}, {0, 1}, {2, 3}, {-1, -1}},
+		{{4, 6}, {4, 5}, {-1, -1}, {-1, -1}},
+		{{7, 9}, {-1, -1}, {-1, -1}, {8, 9}},
+	}
+	if len(m.Submatches) != len(want) {
+		t.Fatalf("got %d submatches, want %d", len(m.Submatches), len(want))
+	}
+	for i, sm := range m.Submatches {
+		got := []span{{sm.Start, sm.End}}
+		for _, g := range sm.Groups {
+			got = append(got, span{g.Start, g.End})
+		}
+		if !slices.Equal(got, want[i]) {
+			t.Errorf("submatch %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
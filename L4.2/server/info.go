@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	pb "grpc-grep/proto"
+)
+
+func (s *server) Info(ctx context.Context, req *pb.InfoRequest) (*pb.InfoResponse, error) {
+	return &pb.InfoResponse{
+		CpuCount:        int32(runtime.NumCPU()),
+		Load1:           loadAverage(),
+		ActiveRequests:  s.active.Load(),
+		MaxRequestLines: int32(s.limits.lines),
+		MaxRequestBytes: int64(s.limits.bytes),
+	}, nil
+}
+
+// loadAverage возвращает среднюю загрузку системы за минуту из
+// /proc/loadavg; на системах без него — 0
+func loadAverage() float64 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return load
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"grpc-grep/internal/lineio"
+	pb "grpc-grep/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// limits — ограничения, которые сервер накладывает на запросы поиска;
+// 0 — без ограничения
+type limits struct {
+	requests   int           // одновременных запросов
+	lines      int           // строк в одном сообщении
+	bytes      int           // байт строк в одном сообщении
+	retryAfter time.Duration // когда клиенту повторить запрос, отклонённый по requests
+}
+
+// admit отмечает начало запроса поиска. Если сервер уже выполняет
+// limits.requests запросов, новый отклоняется с ResourceExhausted и
+// RetryInfo: клиент повторит его позже или отдаст другому серверу.
+// Возвращаемую функцию нужно вызвать по окончании запроса.
+func (s *server) admit() (func(), error) {
+	n := s.active.Add(1)
+	if s.limits.requests > 0 && int(n) > s.limits.requests {
+		s.active.Add(-1)
+		return nil, overloaded(s.limits.retryAfter, "server is busy with %d requests", s.limits.requests)
+	}
+	return func() { s.active.Add(-1) }, nil
+}
+
+// overloaded строит ошибку ResourceExhausted с подсказкой, через сколько
+// повторить запрос
+func overloaded(retryAfter time.Duration, format string, args ...any) error {
+	st := status.Newf(codes.ResourceExhausted, format, args...)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// requestLines возвращает строки сообщения: из block, если он есть, иначе
+// из lines. Сообщение больше limits.bytes или limits.lines отклоняется с
+// ResourceExhausted без RetryInfo: повтор того же сообщения не поможет.
+func (s *server) requestLines(req *pb.GrepRequest) ([]string, error) {
+	size := len(req.Block)
+	if size == 0 {
+		size = int(lineio.Size(req.Lines))
+	}
+	if s.limits.bytes > 0 && size > s.limits.bytes {
+		return nil, status.Errorf(codes.ResourceExhausted, "message of %d bytes exceeds limit of %d", size, s.limits.bytes)
+	}
+
+	lines := req.Lines
+	if len(req.Block) > 0 {
+		lines = lineio.SplitBlock(req.Block)
+	}
+	if s.limits.lines > 0 && len(lines) > s.limits.lines {
+		return nil, status.Errorf(codes.ResourceExhausted, "message of %d lines exceeds limit of %d", len(lines), s.limits.lines)
+	}
+	return lines, nil
+}
+
+// linesPerTask — сколько строк проверяет одна задача пула
+const linesPerTask = 1024
+
+// workerPool — горутины проверки строк, общие для всех запросов сервера:
+// сколько бы запросов ни выполнялось, строки проверяют не больше size
+// горутин. Задачи разных запросов встают в одну очередь.
+type workerPool struct {
+	tasks chan func()
+}
+
+func newWorkerPool(size int) *workerPool {
+	p := &workerPool{tasks: make(chan func())}
+	for range max(size, 1) {
+		go func() {
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+// each делит [0, n) на части по linesPerTask, вызывает fn для каждой на
+// горутинах пула и ждёт, пока все закончатся. Без пула (nil) fn
+// вызывается в текущей горутине.
+func (p *workerPool) each(n int, fn func(lo, hi int)) {
+	if p == nil {
+		fn(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += linesPerTask {
+		hi := min(lo+linesPerTask, n)
+		wg.Add(1)
+		p.tasks <- func() {
+			defer wg.Done()
+			fn(lo, hi)
+		}
+	}
+	wg.Wait()
+}
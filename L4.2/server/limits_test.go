@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	pb "grpc-grep/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryInfo возвращает RetryInfo из деталей ошибки или nil
+func retryInfo(err error) *errdetails.RetryInfo {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+func TestAdmissionLimits(t *testing.T) {
+	client := newBufconnClient(t, &server{
+		limits:  limits{requests: 1, lines: 5000, retryAfter: 250 * time.Millisecond},
+		workers: newWorkerPool(2),
+	})
+	ctx := context.Background()
+
+	// Открытый поток занимает единственное место
+	stream, err := client.GrepStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&pb.GrepRequest{Pattern: "x", Lines: []string{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Grep(ctx, &pb.GrepRequest{Pattern: "x", Lines: []string{"x"}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("busy server: err = %v, want ResourceExhausted", err)
+	}
+	if info := retryInfo(err); info == nil || info.RetryDelay.AsDuration() != 250*time.Millisecond {
+		t.Errorf("busy server: retry info = %v, want 250ms", info)
+	}
+
+	stream.CloseSend()
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("stream: expected EOF")
+	}
+
+	// Слишком большое сообщение отклоняется без подсказки повторить
+	_, err = client.Grep(ctx, &pb.GrepRequest{Pattern: "x", Lines: make([]string, 5001)})
+	if status.Code(err) != codes.ResourceExhausted || retryInfo(err) != nil {
+		t.Errorf("oversized message: err = %v, want ResourceExhausted without retry info", err)
+	}
+
+	// Строки проверяются на общем пуле так же, как без него
+	lines := make([]string, 3000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	opts := Options{lineNum: true}
+	resp, err := client.Grep(ctx, &pb.GrepRequest{Pattern: "7$", LineNum: true, Lines: lines})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _, err := GrepLines(lines, []string{"7$"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plainLines(resp.Matches, opts); !slices.Equal(got, want) {
+		t.Errorf("pool: got %d lines, want %d", len(got), len(want))
+	}
+}
@@ -2,193 +2,227 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net"
-	"regexp"
+	"os"
+	"os/signal"
 	"runtime"
-	"strings"
-	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	pb "grpc-grep/proto"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	// Регистрирует gzip: сервер распаковывает сжатые запросы и отвечает
+	// на них тоже сжатыми ответами
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 )
 
 type server struct {
 	pb.UnimplementedGrepServiceServer
+
+	// root — каталог, внутри которого GrepFile читает файлы; nil, если
+	// доступ к файлам сервера выключен
+	root *os.Root
+
+	// active — запросы поиска, которые выполняются сейчас (для Info и
+	// limits.requests)
+	active atomic.Int32
+
+	limits  limits
+	workers *workerPool // nil — строки проверяются в горутине запроса
 }
 
-type Options struct {
-	after      int
-	before     int
-	countOnly  bool
-	ignore     bool
-	invert     bool
-	fixed      bool
-	lineNum    bool
-	lineOffset int
+// optionsFromRequest переносит параметры поиска из запроса в Options
+func optionsFromRequest(req *pb.GrepRequest) Options {
+	return Options{
+		after:        int(req.After),
+		before:       int(req.Before),
+		countOnly:    req.CountOnly,
+		ignore:       req.Ignore,
+		invert:       req.Invert,
+		fixed:        req.Fixed,
+		lineNum:      req.LineNum,
+		lineOffset:   int(req.LineOffset),
+		word:         req.Word,
+		wholeLine:    req.WholeLine,
+		onlyMatching: req.OnlyMatching,
+		maxCount:     int(max(req.MaxCount, 0)),
+		byteOffset:   req.ByteOffset,
+		submatches:   req.Submatches,
+	}
 }
 
-// compilePattern подготавливает функцию проверки строки
-func compilePattern(pattern string, opts Options) (func(string) bool, error) {
-	if opts.ignore {
-		pattern = "(?i)" + pattern
-	}
-	if opts.fixed {
-		if opts.ignore {
-			pattern = strings.ToLower(pattern)
-			return func(s string) bool {
-				return strings.Contains(strings.ToLower(s), pattern)
-			}, nil
-		}
-		return func(s string) bool {
-			return strings.Contains(s, pattern)
-		}, nil
+// patternsFromRequest возвращает шаблоны запроса; клиенты, которые знают
+// только одиночный pattern, присылают пустой patterns
+func patternsFromRequest(req *pb.GrepRequest) []string {
+	if len(req.Patterns) > 0 {
+		return req.Patterns
 	}
-	re, err := regexp.Compile(pattern)
+	return []string{req.Pattern}
+}
+
+// newGrepper создаёт grepper для запроса; строки он проверяет на общем
+// пуле горутин сервера
+func (s *server) newGrepper(req *pb.GrepRequest, opts Options) (*grepper, error) {
+	g, err := newGrepper(patternsFromRequest(req), opts)
 	if err != nil {
-		return nil, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	return func(s string) bool {
-		return re.MatchString(s)
-	}, nil
+	g.workers = s.workers
+	return g, nil
 }
 
-func GrepLines(
-	lines []string,
-	pattern string,
-	opts Options,
-) ([]string, int, error) {
-	matchFunc, err := compilePattern(pattern, opts)
+func (s *server) Grep(
+	ctx context.Context,
+	req *pb.GrepRequest,
+) (*pb.GrepResponse, error) {
+	done, err := s.admit()
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
+	defer done()
 
-	numLines := len(lines)
-	matched := make([]bool, numLines)
-
-	// Параллельная обработка строк
-	numWorkers := runtime.NumCPU()
-	if numWorkers > numLines {
-		numWorkers = numLines
+	lines, err := s.requestLines(req)
+	if err != nil {
+		return nil, err
 	}
-	if numWorkers < 1 {
-		numWorkers = 1
+	opts := optionsFromRequest(req)
+	g, err := s.newGrepper(req, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	jobs := make(chan int, numLines)
-	var wg sync.WaitGroup
-
-	for w := 0; w < numWorkers; w++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for i := range jobs {
-				ok := matchFunc(lines[i])
-				if opts.invert {
-					ok = !ok
-				}
-				matched[i] = ok
-			}
-		}()
+	out := g.feed(lines, false)
+	if opts.countOnly {
+		return &pb.GrepResponse{Count: int32(g.count)}, nil
 	}
+	return &pb.GrepResponse{Matches: out}, nil
+}
 
-	for i := 0; i < numLines; i++ {
-		jobs <- i
+func (s *server) GrepStream(stream pb.GrepService_GrepStreamServer) error {
+	done, err := s.admit()
+	if err != nil {
+		return err
 	}
-	close(jobs)
-	wg.Wait()
+	defer done()
 
-	if opts.countOnly {
-		count := 0
-		for _, m := range matched {
-			if m {
-				count++
-			}
-		}
-		return nil, count, nil
+	req, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	var out []string
-	printed := make(map[int]bool)
+	// Параметры поиска задаются первым сообщением потока
+	opts := optionsFromRequest(req)
+	g, err := s.newGrepper(req, opts)
+	if err != nil {
+		return err
+	}
 
-	for i := range lines {
-		if !matched[i] {
-			continue
+	for {
+		lines, err := s.requestLines(req)
+		if err != nil {
+			return err
 		}
-
-		start := i - opts.before
-		if start < 0 {
-			start = 0
+		if out := g.feed(lines, req.Halo); len(out) > 0 {
+			if err := stream.Send(&pb.GrepResponse{Matches: out}); err != nil {
+				return err
+			}
 		}
-		end := i + opts.after
-		if end >= len(lines) {
-			end = len(lines) - 1
+		// Лимит -m достигнут: остаток части можно не принимать
+		if g.done() {
+			break
 		}
 
-		for j := start; j <= end; j++ {
-			if printed[j] {
-				continue
-			}
-			printed[j] = true
-
-			if opts.lineNum {
-				out = append(out, fmt.Sprintf("%d:%s", opts.lineOffset+j+1, lines[j]))
-			} else {
-				out = append(out, lines[j])
-			}
+		req, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
 		}
 	}
 
-	return out, 0, nil
-}
-
-func (s *server) Grep(
-	ctx context.Context,
-	req *pb.GrepRequest,
-) (*pb.GrepResponse, error) {
-	opts := Options{
-		after:      int(req.After),
-		before:     int(req.Before),
-		countOnly:  req.CountOnly,
-		ignore:     req.Ignore,
-		invert:     req.Invert,
-		fixed:      req.Fixed,
-		lineNum:    req.LineNum,
-		lineOffset: int(req.LineOffset),
-	}
-
-	out, count, err := GrepLines(
-		req.Lines,
-		req.Pattern,
-		opts,
-	)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	if opts.countOnly {
+		return stream.Send(&pb.GrepResponse{Count: int32(g.count)})
 	}
-
-	return &pb.GrepResponse{
-		Output: out,
-		Count:  int32(count),
-	}, nil
+	return nil
 }
 
 func main() {
 	port := flag.Int("port", 50053, "gRPC server port")
+	rootDir := flag.String("root", "", "Directory served to GrepFile; file access is disabled if empty")
+	maxRequests := flag.Int("max-requests", 64, "Maximum concurrent search requests; 0 means unlimited")
+	maxLines := flag.Int("max-request-lines", 0, "Maximum lines in one request message; 0 means unlimited")
+	maxBytes := flag.Int("max-request-bytes", 8<<20, "Maximum bytes of lines in one request message; 0 means unlimited")
+	workers := flag.Int("workers", runtime.NumCPU(), "Goroutines matching lines, shared by all requests")
+	retryAfter := flag.Duration("retry-after", 500*time.Millisecond, "Delay suggested to clients rejected by -max-requests")
 	flag.Parse()
 
+	srv := &server{
+		limits: limits{
+			requests:   *maxRequests,
+			lines:      *maxLines,
+			bytes:      *maxBytes,
+			retryAfter: *retryAfter,
+		},
+		workers: newWorkerPool(*workers),
+	}
+	if *rootDir != "" {
+		root, err := os.OpenRoot(*rootDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer root.Close()
+		srv.root = root
+		log.Printf("serving files from %s", *rootDir)
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterGrepServiceServer(grpcServer, &server{})
+	// Сообщение до -max-request-bytes должно дойти до requestLines, чтобы
+	// отказ был понятным; запас — на разметку protobuf
+	maxMsg := math.MaxInt32
+	if *maxBytes > 0 {
+		maxMsg = max(*maxBytes+1<<20, 4<<20)
+	}
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxMsg))
+	pb.RegisterGrepServiceServer(grpcServer, srv)
+
+	// grpc.health.v1: клиенты проверяют сервер до того, как дать ему секции
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(pb.GrepService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthSrv)
+
+	// При остановке сервер сначала объявляет себя NOT_SERVING, чтобы
+	// клиенты перестали давать ему секции, и дорабатывает начатые запросы
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		log.Print("shutting down")
+		healthSrv.Shutdown()
+		grpcServer.GracefulStop()
+	}()
 
 	log.Printf("gRPC server listening on :%d", *port)
-	log.Fatal(grpcServer.Serve(lis))
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"grpc-grep/internal/lineio"
+	pb "grpc-grep/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newBufconnClient запускает s в памяти и возвращает клиента к нему
+func newBufconnClient(tb testing.TB, s *server, opts ...grpc.DialOption) pb.GrepServiceClient {
+	tb.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterGrepServiceServer(srv, s)
+	go srv.Serve(lis)
+	tb.Cleanup(srv.Stop)
+
+	opts = append(opts,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufconn", opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	return pb.NewGrepServiceClient(conn)
+}
+
+// streamBatches отправляет батчи через GrepStream и собирает ответы
+func streamBatches(ctx context.Context, client pb.GrepServiceClient, batches []*pb.GrepRequest) ([]*pb.Match, int32, error) {
+	stream, err := client.GrepStream(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	go func() {
+		for _, req := range batches {
+			if stream.Send(req) != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	var out []*pb.Match
+	var count int32
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return out, count, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, resp.Matches...)
+		count += resp.Count
+	}
+}
+
+// splitBatches делит строки на батчи по size строк в формате lines или block
+func splitBatches(query *pb.GrepRequest, lines []string, size int, block bool) []*pb.GrepRequest {
+	var batches []*pb.GrepRequest
+	for start := 0; start < len(lines); start += size {
+		req := &pb.GrepRequest{}
+		if start == 0 {
+			req = query
+		}
+		part := lines[start:min(start+size, len(lines))]
+		if block {
+			req.Block = lineio.JoinBlock(part)
+		} else {
+			req.Lines = part
+		}
+		batches = append(batches, req)
+	}
+	return batches
+}
+
+func TestGrepStreamBlockMatchesLines(t *testing.T) {
+	client := newBufconnClient(t, &server{})
+	query := func() *pb.GrepRequest {
+		return &pb.GrepRequest{Pattern: "match", After: 1, Before: 2, Submatches: true}
+	}
+
+	want, _, err := streamBatches(context.Background(), client, splitBatches(query(), boundaryLines, 3, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := streamBatches(context.Background(), client, splitBatches(query(), boundaryLines, 3, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(got, want, func(a, b *pb.Match) bool { return a.String() == b.String() }) {
+		t.Errorf("block: got %v\nwant %v", got, want)
+	}
+
+	// Строки не в UTF-8 проходят только блоком и возвращаются байт в байт
+	binary := []string{"ok", "bad \xff match", "\xc3"}
+	got, _, err = streamBatches(context.Background(), client, splitBatches(query(), binary, 2, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || string(got[1].Text) != binary[1] || got[1].Context {
+		t.Errorf("binary: got %v", got)
+	}
+}
+
+// BenchmarkGrepStreamPayload сравнивает передачу строк списком string и
+// блоком bytes, со сжатием gzip и без
+func BenchmarkGrepStreamPayload(b *testing.B) {
+	words := []string{"alpha", "beta", "gamma", "delta", "info", "warn", "ERROR"}
+	lines := make([]string, 100_000)
+	size := 0
+	for i := range lines {
+		lines[i] = fmt.Sprintf("2024-05-01T12:%02d:%02d %s request id=%d %s",
+			i/60%60, i%60, words[i%len(words)], i, strings.Repeat(words[i*7%len(words)]+" ", 4))
+		size += len(lines[i]) + 1
+	}
+
+	workers := newWorkerPool(runtime.NumCPU())
+	for _, payload := range []string{"lines", "block"} {
+		for _, compress := range []string{"none", "gzip"} {
+			b.Run(payload+"/"+compress, func(b *testing.B) {
+				var opts []grpc.DialOption
+				if compress == "gzip" {
+					opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+				}
+				client := newBufconnClient(b, &server{workers: workers}, opts...)
+
+				// Около 1 МБ на сообщение, как у клиента
+				query := &pb.GrepRequest{Pattern: "ERROR", CountOnly: true}
+				batches := splitBatches(query, lines, 15_000, payload == "block")
+
+				b.SetBytes(int64(size))
+				for b.Loop() {
+					if _, count, err := streamBatches(context.Background(), client, batches); err != nil || count == 0 {
+						b.Fatal(count, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestInfoCountsActiveRequests(t *testing.T) {
+	client := newBufconnClient(t, &server{})
+	ctx := context.Background()
+
+	stream, err := client.GrepStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&pb.GrepRequest{Pattern: "x", Lines: []string{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Ответ на первый батч значит, что сервер уже внутри GrepStream
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.Info(ctx, &pb.InfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CpuCount < 1 || info.ActiveRequests != 1 {
+		t.Errorf("info = %v, want cpu_count >= 1 and one active request", info)
+	}
+
+	stream.CloseSend()
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Fatalf("stream end: %v", err)
+	}
+	if info, _ = client.Info(ctx, &pb.InfoRequest{}); info.ActiveRequests != 0 {
+		t.Errorf("after stream: active_requests = %d, want 0", info.ActiveRequests)
+	}
+}